@@ -0,0 +1,69 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+type ApprovalVote struct {
+	// The identifiers of the approved projects, in file order.
+	Vote []string
+
+	voteBase
+}
+
+type ApprovalPB struct {
+	*pbBase
+}
+
+func newApprovalVote(base voteBase) (ret ApprovalVote) {
+	ret.voteBase = base
+	ret.Vote = ret.mustList("vote")
+	return
+}
+
+// Approves returns whether the project with given identifier is approved.
+func (self ApprovalVote) Approves(id string) bool {
+	for _, approved := range self.Vote {
+		if approved == id {
+			return true
+		}
+	}
+	return false
+}
+
+func newApprovalPB(file *File) (ret ApprovalPB, err error) {
+	ret = ApprovalPB{}
+	ret.pbBase, err = newPbBase(file)
+	return
+}
+
+func (self ApprovalPB) Vote(index int) Vote {
+	return newApprovalVote(newVoteBase(self.votesSection, index))
+}
+
+func (self ApprovalPB) MinLength() int {
+	return self.defaultMetaInt("min_length", 1)
+}
+
+func (self ApprovalPB) MaxLength() int {
+	return self.defaultMetaInt("max_length", self.CountProjects())
+}
+
+// MaxSumCost returns the maximal total cost of the approved projects of each
+// vote. There is no such limit when the meta key is absent, in which case the
+// maximal int value is returned.
+func (self ApprovalPB) MaxSumCost() int {
+	return self.defaultMetaInt("max_sum_cost", maxInt)
+}
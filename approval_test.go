@@ -0,0 +1,173 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"testing"
+)
+
+type approvalVoteRepr struct {
+	id   string
+	vote []string
+}
+
+func TestApprovalPB(t *testing.T) {
+	tests := []struct {
+		name       string
+		repr       []namedSection
+		minLength  int
+		maxLength  int
+		maxSumCost int
+		votes      []approvalVoteRepr
+	}{
+		{
+			name: "full",
+			repr: []namedSection{
+				{
+					name: "META",
+					section: Section{
+						Fields: []string{"key", "value"},
+						Lines: [][]string{
+							{"num_projects", "3"},
+							{"num_votes", "3"},
+							{"budget", "1000"},
+							{"vote_type", "approval"},
+							{"min_length", "0"},
+							{"max_length", "2"},
+							{"max_sum_cost", "1500"},
+							{"rule", "greedy"},
+						},
+					},
+				},
+				{
+					name: "PROJECTS",
+					section: Section{
+						Fields: []string{"project_id", "cost"},
+						Lines: [][]string{
+							{"1", "999"},
+							{"2", "500"},
+							{"3", "200"},
+						},
+					},
+				},
+				{
+					name: "VOTES",
+					section: Section{
+						Fields: []string{"voter_id", "vote"},
+						Lines: [][]string{
+							{"0", "1,2"},
+							{"1", "3, 1"},
+							{"2", ""},
+						},
+					},
+				},
+			},
+			minLength:  0,
+			maxLength:  2,
+			maxSumCost: 1500,
+			votes: []approvalVoteRepr{
+				{id: "0", vote: []string{"1", "2"}},
+				{id: "1", vote: []string{"3", "1"}},
+				{id: "2", vote: nil},
+			},
+		},
+		{
+			name: "default",
+			repr: []namedSection{
+				{
+					name: "META",
+					section: Section{
+						Fields: []string{"key", "value"},
+						Lines: [][]string{
+							{"num_projects", "3"},
+							{"num_votes", "1"},
+							{"budget", "1000"},
+							{"vote_type", "approval"},
+							{"rule", "greedy"},
+						},
+					},
+				},
+				{
+					name: "PROJECTS",
+					section: Section{
+						Fields: []string{"project_id", "cost"},
+						Lines: [][]string{
+							{"1", "999"},
+							{"2", "500"},
+							{"3", "200"},
+						},
+					},
+				},
+				{
+					name: "VOTES",
+					section: Section{
+						Fields: []string{"voter_id", "vote"},
+						Lines: [][]string{
+							{"0", "2"},
+						},
+					},
+				},
+			},
+			minLength:  1,
+			maxLength:  3,
+			maxSumCost: maxInt,
+			votes: []approvalVoteRepr{
+				{id: "0", vote: []string{"2"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pb, err := newApprovalPB(makeFile(tt.repr))
+			mustt(t, err)
+
+			if got := pb.MinLength(); got != tt.minLength {
+				t.Errorf("Wrong MinLength. Got %d. Expect %d.", got, tt.minLength)
+			}
+			if got := pb.MaxLength(); got != tt.maxLength {
+				t.Errorf("Wrong MaxLength. Got %d. Expect %d.", got, tt.maxLength)
+			}
+			if got := pb.MaxSumCost(); got != tt.maxSumCost {
+				t.Errorf("Wrong MaxSumCost. Got %d. Expect %d.", got, tt.maxSumCost)
+			}
+
+			for i, expect := range tt.votes {
+				vote, ok := pb.Vote(i).(ApprovalVote)
+				if !ok {
+					t.Errorf("Vote %v not of type ApprovalVote", pb.Vote(i))
+					continue
+				}
+				if got := vote.Id(); got != expect.id {
+					t.Errorf("Wrong Id for vote %d. Got %s. Expect %s.", i, got, expect.id)
+				}
+				if !reflect.DeepEqual(vote.Vote, expect.vote) {
+					t.Errorf("Wrong Vote for vote %d. Got %v. Expect %v.", i, vote.Vote, expect.vote)
+				}
+				for _, id := range expect.vote {
+					if !vote.Approves(id) {
+						t.Errorf("Vote %d does not approve %s.", i, id)
+					}
+				}
+				if vote.Approves("42") {
+					t.Errorf("Vote %d approves unknown project.", i)
+				}
+			}
+		})
+	}
+}
@@ -55,6 +55,44 @@ func (self MissingRequiredMeta) Error() string {
 	return fmt.Sprintf("Missing required meta key %s", self.Meta)
 }
 
+type UnknownVoteType struct {
+	VoteType string
+}
+
+func (self UnknownVoteType) Error() string {
+	return fmt.Sprintf("Unknown vote type %s", self.VoteType)
+}
+
+type UnknownProject struct {
+	Project string
+}
+
+func (self UnknownProject) Error() string {
+	return fmt.Sprintf("Unknown project %s", self.Project)
+}
+
+type InvalidMeta struct {
+	Meta  string
+	Value string
+}
+
+func (self InvalidMeta) Error() string {
+	return fmt.Sprintf("Invalid value %q for meta key %s", self.Value, self.Meta)
+}
+
+type InvalidField struct {
+	Section string
+	// The index of the line in Section.Lines.
+	Line  int
+	Field string
+	Value string
+}
+
+func (self InvalidField) Error() string {
+	return fmt.Sprintf("Invalid value %q for field %s at line %d of section %s",
+		self.Value, self.Field, self.Line, self.Section)
+}
+
 // Generic types //
 
 const (
@@ -70,6 +108,14 @@ const (
 	RuleUnknown
 )
 
+// Names of the rules, as used in the rule meta key and in the rule registry.
+const (
+	RuleNameGreedy      = "greedy"
+	RuleNameUnitCost    = "unit-cost"
+	RuleNameEqualShares = "equalshares"
+	RuleNameCondorcet   = "Condorcet"
+)
+
 type Project interface {
 	Id() string
 	Cost() int
@@ -83,12 +129,25 @@ type Vote interface {
 	Field(name string) (string, bool)
 }
 
+// PB is a participatory budgeting instance. The implementations of this
+// package check all required values when they are created, so that no method
+// panics when called with valid indexes.
 type PB interface {
+	// NumProjects returns the num_projects meta value. It may differ from the
+	// number of lines in the PROJECTS section.
 	NumProjects() int
+	// NumVotes returns the num_votes meta value. It may differ from the number
+	// of lines in the VOTES section.
 	NumVotes() int
+	// CountProjects returns the number of lines in the PROJECTS section.
+	CountProjects() int
+	// CountVotes returns the number of lines in the VOTES section.
+	CountVotes() int
 	Budget() int
 	VoteType() int
-	Rule() int
+	// Rule returns the value of the rule meta key. It can be looked up with
+	// LookupRule.
+	Rule() string
 
 	Meta(key string) (string, bool)
 
@@ -103,13 +162,20 @@ type PB interface {
 
 // Base implementation //
 
+// maxInt is used as default value for unbounded meta keys.
+const maxInt = int(^uint(0) >> 1)
+
 type fieldBased struct {
 	section *Section
-	line    int
+	// The index of the line in the whole section, used in error reports.
+	line int
+	// The index in the whole section of the first line of section, for
+	// sections holding only some of its lines.
+	offset int
 }
 
 func (self fieldBased) Field(name string) (string, bool) {
-	return self.section.Cell(self.line, name)
+	return self.section.Cell(self.line-self.offset, name)
 }
 
 func (self fieldBased) mustField(name string) (ret string) {
@@ -121,6 +187,19 @@ func (self fieldBased) mustField(name string) (ret string) {
 	return
 }
 
+// mustList splits a comma separated field. An empty field results in an empty
+// list.
+func (self fieldBased) mustList(name string) []string {
+	return splitList(self.mustField(name))
+}
+
+func splitList(str string) []string {
+	if str == "" {
+		return nil
+	}
+	return spliterComma.Split(str, -1)
+}
+
 type projectBase struct {
 	fieldBased
 }
@@ -154,12 +233,23 @@ func (self voteBase) Id() string {
 	return self.mustField("voter_id")
 }
 
+// basedPB is implemented by all PB types of this package.
+type basedPB interface {
+	base() *pbBase
+}
+
 type pbBase struct {
 	metaSection     *Section
 	projectsSection *Section
 	votesSection    *Section
-	budget          int // memoized
+	budget          int    // memoized
+	numProjects     int    // memoized
+	numVotes        int    // memoized
+	voteType        int    // memoized
+	rule            string // memoized
 	projectId       map[string]int
+	// The value of each meta key. Only the first occurrence of a key is kept.
+	meta map[string]string
 }
 
 func firstMissingField(fields []string, indexes []int) string {
@@ -187,16 +277,26 @@ func newPbBase(file *File) (ret *pbBase, err error) {
 	}
 
 	// Meta
-	var strBudget string
-	if strBudget, ok = ret.Meta("budget"); !ok {
-		return ret, MissingRequiredMeta{"budget"}
+	ret.meta = make(map[string]string, len(ret.metaSection.Lines))
+	for _, line := range ret.metaSection.Lines {
+		if _, dup := ret.meta[line[0]]; !dup {
+			ret.meta[line[0]] = line[1]
+		}
+	}
+	if err = ret.hasAllMeta([]string{"budget", "num_projects", "num_votes", "vote_type", "rule"}); err != nil {
+		return
+	}
+	if ret.budget, err = ret.metaInt("budget"); err != nil {
+		return
 	}
-	if ret.budget, err = strconv.Atoi(strBudget); err != nil {
+	if ret.numProjects, err = ret.metaInt("num_projects"); err != nil {
 		return
 	}
-	if err = ret.hasAllMeta([]string{"num_projects", "num_votes", "vote_type", "rule"}); err != nil {
+	if ret.numVotes, err = ret.metaInt("num_votes"); err != nil {
 		return
 	}
+	ret.voteType = parseVoteType(ret.mustMeta("vote_type"))
+	ret.rule = ret.mustMeta("rule")
 
 	// Fields
 	var (
@@ -212,14 +312,21 @@ func newPbBase(file *File) (ret *pbBase, err error) {
 	}
 
 	// Projects
-	ret.projectId = make(map[string]int, ret.NumProjects())
+	ret.projectId = make(map[string]int, ret.CountProjects())
 	for i, project := range ret.projectsSection.Lines {
 		ret.projectId[project[projectIndexes[0]]] = i
+		if _, err = strconv.Atoi(project[projectIndexes[1]]); err != nil {
+			return ret, InvalidField{Section: "PROJECTS", Line: i, Field: "cost", Value: project[projectIndexes[1]]}
+		}
 	}
 
 	return
 }
 
+func (self *pbBase) base() *pbBase {
+	return self
+}
+
 func (self *pbBase) hasAllMeta(meta []string) error {
 	count := len(meta)
 	metaMap := make(map[string]bool, count)
@@ -243,20 +350,69 @@ func (self *pbBase) hasAllMeta(meta []string) error {
 		}
 	}
 
-	for key, fnd := range metaMap {
-		if !fnd {
-			return MissingRequiredField{key}
+	for _, key := range meta {
+		if !metaMap[key] {
+			return MissingRequiredMeta{key}
 		}
 	}
 	panic("Must never reach this line")
 }
 
+// checkPoints checks that the VOTES section has a points field, and that each
+// vote has as many integer points as voted projects.
+func (self *pbBase) checkPoints() error {
+	indexes, err := self.pointsIndexes()
+	if err != nil {
+		return err
+	}
+	for i, line := range self.votesSection.Lines {
+		if err = checkPointsLine(indexes, line, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pointsIndexes returns the indexes of the vote and points fields.
+func (self *pbBase) pointsIndexes() ([]int, error) {
+	fields := []string{"vote", "points"}
+	indexes, ok := self.votesSection.FieldIndexes(fields)
+	if !ok {
+		return nil, MissingRequiredField{firstMissingField(fields, indexes)}
+	}
+	return indexes, nil
+}
+
+// checkPointsLine checks the vote at index i, given the indexes of the vote
+// and points fields.
+func checkPointsLine(indexes []int, line []string, i int) error {
+	projects := splitList(line[indexes[0]])
+	points := splitList(line[indexes[1]])
+	if len(projects) != len(points) {
+		return InvalidField{Section: "VOTES", Line: i, Field: "points", Value: line[indexes[1]]}
+	}
+	for _, str := range points {
+		if _, err := strconv.Atoi(str); err != nil {
+			return InvalidField{Section: "VOTES", Line: i, Field: "points", Value: line[indexes[1]]}
+		}
+	}
+	return nil
+}
+
 func (self *pbBase) NumProjects() int {
-	return self.mustMetaInt("num_projects")
+	return self.numProjects
 }
 
 func (self *pbBase) NumVotes() int {
-	return self.mustMetaInt("num_votes")
+	return self.numVotes
+}
+
+func (self *pbBase) CountProjects() int {
+	return len(self.projectsSection.Lines)
+}
+
+func (self *pbBase) CountVotes() int {
+	return len(self.votesSection.Lines)
 }
 
 func (self *pbBase) Budget() int {
@@ -264,7 +420,11 @@ func (self *pbBase) Budget() int {
 }
 
 func (self *pbBase) VoteType() int {
-	switch self.mustMeta("vote_type") {
+	return self.voteType
+}
+
+func parseVoteType(str string) int {
+	switch str {
 	case "approval":
 		return VoteTypeApproval
 	case "ordinal":
@@ -278,20 +438,13 @@ func (self *pbBase) VoteType() int {
 	}
 }
 
-func (self *pbBase) Rule() int {
-	switch self.mustMeta("rule") {
-	case "greedy":
-		return RuleGreedy
-	default:
-		return RuleUnknown
-	}
+func (self *pbBase) Rule() string {
+	return self.rule
 }
 
 func (self *pbBase) Meta(key string) (string, bool) {
-	for _, line := range self.metaSection.Lines {
-		if line[0] == key {
-			return line[1], true
-		}
+	if value, ok := self.meta[key]; ok {
+		return value, true
 	}
 	return key, false
 }
@@ -304,10 +457,14 @@ func (self *pbBase) mustMeta(key string) (ret string) {
 	return
 }
 
-func (self *pbBase) mustMetaInt(key string) (ret int) {
-	var err error
-	if ret, err = strconv.Atoi(self.mustMeta(key)); err != nil {
-		panic(err)
+// metaInt returns the integer value of a required meta key.
+func (self *pbBase) metaInt(key string) (ret int, err error) {
+	str, ok := self.Meta(key)
+	if !ok {
+		return 0, MissingRequiredMeta{key}
+	}
+	if ret, err = strconv.Atoi(str); err != nil {
+		err = InvalidMeta{Meta: key, Value: str}
 	}
 	return
 }
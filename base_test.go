@@ -66,7 +66,7 @@ func TestPBBase(t *testing.T) {
 		numVotes    int
 		budget      int
 		voteType    int
-		rule        int
+		rule        string
 		projects    []projectRepr
 		votes       []string
 	}{
@@ -112,7 +112,7 @@ func TestPBBase(t *testing.T) {
 			numVotes:    3,
 			budget:      1000,
 			voteType:    VoteTypeApproval,
-			rule:        RuleGreedy,
+			rule:        RuleNameGreedy,
 			projects: []projectRepr{
 				{id: "1", cost: 999},
 				{id: "2", cost: 998},
@@ -139,7 +139,7 @@ func TestPBBase(t *testing.T) {
 				t.Errorf("Wrong VoteType. Got %d. Expect %d.", got, tt.voteType)
 			}
 			if got := pb.Rule(); got != tt.rule {
-				t.Errorf("Wrong Rule. Got %d. Expect %d.", got, tt.rule)
+				t.Errorf("Wrong Rule. Got %s. Expect %s.", got, tt.rule)
 			}
 
 			for i, expect := range tt.projects {
@@ -163,3 +163,123 @@ func TestPBBase(t *testing.T) {
 		})
 	}
 }
+
+func TestNewPbBase_Errors(t *testing.T) {
+	makeRepr := func(meta [][]string, costs []string, votesFields []string, votes [][]string) []namedSection {
+		projects := make([][]string, len(costs))
+		for i, cost := range costs {
+			projects[i] = []string{string(rune('1' + i)), cost}
+		}
+		return []namedSection{
+			{name: "META", section: Section{Fields: []string{"key", "value"}, Lines: meta}},
+			{name: "PROJECTS", section: Section{Fields: []string{"project_id", "cost"}, Lines: projects}},
+			{name: "VOTES", section: Section{Fields: votesFields, Lines: votes}},
+		}
+	}
+	validMeta := [][]string{
+		{"num_projects", "2"},
+		{"num_votes", "1"},
+		{"budget", "1000"},
+		{"vote_type", "cumulative"},
+		{"rule", "greedy"},
+	}
+
+	tests := []struct {
+		name string
+		repr []namedSection
+		err  error
+	}{
+		{
+			name: "Invalid budget",
+			repr: makeRepr(
+				[][]string{{"budget", "lots"}, {"num_projects", "2"}, {"num_votes", "1"}, {"vote_type", "approval"}, {"rule", "greedy"}},
+				[]string{"1", "2"}, []string{"voter_id", "vote"}, [][]string{{"0", "1"}}),
+			err: InvalidMeta{Meta: "budget", Value: "lots"},
+		},
+		{
+			name: "Invalid num_votes",
+			repr: makeRepr(
+				[][]string{{"budget", "10"}, {"num_projects", "2"}, {"num_votes", "?"}, {"vote_type", "approval"}, {"rule", "greedy"}},
+				[]string{"1", "2"}, []string{"voter_id", "vote"}, [][]string{{"0", "1"}}),
+			err: InvalidMeta{Meta: "num_votes", Value: "?"},
+		},
+		{
+			name: "Missing rule",
+			repr: makeRepr(
+				[][]string{{"budget", "10"}, {"num_projects", "2"}, {"num_votes", "1"}, {"vote_type", "approval"}},
+				[]string{"1", "2"}, []string{"voter_id", "vote"}, [][]string{{"0", "1"}}),
+			err: MissingRequiredMeta{"rule"},
+		},
+		{
+			name: "Invalid cost",
+			repr: makeRepr(validMeta, []string{"1", "2.5"}, []string{"voter_id", "vote"}, [][]string{{"0", "1"}}),
+			err:  InvalidField{Section: "PROJECTS", Line: 1, Field: "cost", Value: "2.5"},
+		},
+		{
+			name: "Missing points",
+			repr: makeRepr(validMeta, []string{"1", "2"}, []string{"voter_id", "vote"}, [][]string{{"0", "1"}}),
+			err:  MissingRequiredField{"points"},
+		},
+		{
+			name: "Wrong points length",
+			repr: makeRepr(validMeta, []string{"1", "2"}, []string{"voter_id", "vote", "points"}, [][]string{{"0", "1,2", "3"}}),
+			err:  InvalidField{Section: "VOTES", Line: 0, Field: "points", Value: "3"},
+		},
+		{
+			name: "Invalid points",
+			repr: makeRepr(validMeta, []string{"1", "2"}, []string{"voter_id", "vote", "points"}, [][]string{{"0", "1", "x"}}),
+			err:  InvalidField{Section: "VOTES", Line: 0, Field: "points", Value: "x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPB(makeFile(tt.repr))
+			if err != tt.err {
+				t.Errorf("Got error %v. Expect error %v.", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPBBase_RowCounts(t *testing.T) {
+	pb, err := makePBBase([]namedSection{
+		{
+			name: "META",
+			section: Section{
+				Fields: []string{"key", "value"},
+				Lines: [][]string{
+					{"num_projects", "5"},
+					{"num_votes", "5"},
+					{"budget", "1000"},
+					{"vote_type", "approval"},
+					{"rule", "greedy"},
+				},
+			},
+		},
+		{
+			name:    "PROJECTS",
+			section: Section{Fields: []string{"project_id", "cost"}, Lines: [][]string{{"1", "999"}}},
+		},
+		{
+			name:    "VOTES",
+			section: Section{Fields: []string{"voter_id", "vote"}, Lines: [][]string{{"0", "1"}, {"1", "1"}}},
+		},
+	})
+	mustt(t, err)
+
+	if got := pb.NumProjects(); got != 5 {
+		t.Errorf("Wrong NumProjects. Got %d. Expect %d.", got, 5)
+	}
+	if got := pb.NumVotes(); got != 5 {
+		t.Errorf("Wrong NumVotes. Got %d. Expect %d.", got, 5)
+	}
+	if got := pb.CountProjects(); got != 1 {
+		t.Errorf("Wrong CountProjects. Got %d. Expect %d.", got, 1)
+	}
+	if got := pb.CountVotes(); got != 2 {
+		t.Errorf("Wrong CountVotes. Got %d. Expect %d.", got, 2)
+	}
+	for i := 0; i < pb.CountVotes(); i++ {
+		pb.Vote(i).Id()
+	}
+}
@@ -0,0 +1,271 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	benchProjects = 100
+	benchVotes    = 100000
+)
+
+var (
+	benchOnce sync.Once
+	benchData string
+)
+
+// largeFile returns a generated approval file with many votes, each having a
+// few extra fields, as in real pabulib files.
+func largeFile() string {
+	benchOnce.Do(func() {
+		random := rand.New(rand.NewSource(1))
+		var data strings.Builder
+		fmt.Fprintf(&data, "META\nkey;value\ndescription;Generated\ncountry;Nowhere\nunit;Bench\n")
+		fmt.Fprintf(&data, "num_projects;%d\nnum_votes;%d\nbudget;%d\n", benchProjects, benchVotes, benchProjects*500)
+		fmt.Fprintf(&data, "vote_type;approval\nrule;greedy\nmax_length;10\n")
+		data.WriteString("PROJECTS\nproject_id;cost;name;category\n")
+		for i := 0; i < benchProjects; i++ {
+			fmt.Fprintf(&data, "%d;%d;Project %d;misc\n", i, 100+random.Intn(1000), i)
+		}
+		data.WriteString("VOTES\nvoter_id;age;sex;voting_method;vote\n")
+		for i := 0; i < benchVotes; i++ {
+			fmt.Fprintf(&data, "%d;%d;%c;internet;", i, 18+random.Intn(60), "MF"[random.Intn(2)])
+			for j, project := range random.Perm(benchProjects)[:1+random.Intn(10)] {
+				if j > 0 {
+					data.WriteByte(',')
+				}
+				fmt.Fprintf(&data, "%d", project)
+			}
+			data.WriteByte('\n')
+		}
+		benchData = data.String()
+	})
+	return benchData
+}
+
+func benchPB(b *testing.B) PB {
+	pb, err := Open(strings.NewReader(largeFile()))
+	if err != nil {
+		b.Fatal(err)
+	}
+	return pb
+}
+
+func BenchmarkOpen(b *testing.B) {
+	data := largeFile()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Open(strings.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTally(b *testing.B) {
+	pb := benchPB(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		count := make(map[string]int, pb.CountProjects())
+		for v := 0; v < pb.CountVotes(); v++ {
+			for _, project := range pb.Vote(v).(ApprovalVote).Vote {
+				count[project] += 1
+			}
+		}
+	}
+}
+
+func BenchmarkVoteField(b *testing.B) {
+	pb := benchPB(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for v := 0; v < pb.CountVotes(); v++ {
+			if _, ok := pb.Vote(v).Field("age"); !ok {
+				b.Fatal("Missing field age")
+			}
+		}
+	}
+}
+
+func BenchmarkMeta(b *testing.B) {
+	pb := benchPB(b).(ApprovalPB)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pb.MaxLength()
+		pb.MinLength()
+		pb.MaxSumCost()
+	}
+}
+
+// The lookups done before fields and meta keys were indexed, as baselines.
+
+func baselineFieldIndexes(section *Section, fields []string) (indexes []int, ok bool) {
+	count := len(fields)
+	indexes = make([]int, count)
+	posMap := make(map[string]int, count)
+	for i, field := range fields {
+		indexes[i] = -1
+		posMap[field] = i
+	}
+
+	found := 0
+	for i, field := range section.Fields {
+		pos, tmpOk := posMap[field]
+		if tmpOk {
+			if indexes[pos] < 0 {
+				found += 1
+			}
+			indexes[pos] = i
+			if found == count {
+				break
+			}
+		}
+	}
+
+	ok = found == count
+	return
+}
+
+func baselineCell(section *Section, line int, field string) (string, bool) {
+	if line >= len(section.Lines) {
+		return "", false
+	}
+	index, ok := baselineFieldIndexes(section, []string{field})
+	if !ok {
+		return "", false
+	}
+	return section.Lines[line][index[0]], true
+}
+
+func baselineMetaInt(section *Section, key string, _default int) int {
+	for _, line := range section.Lines {
+		if line[0] == key {
+			if ret, err := strconv.Atoi(line[1]); err == nil {
+				return ret
+			}
+			break
+		}
+	}
+	return _default
+}
+
+func benchCells(b *testing.B, cell func(*Section, int, string) (string, bool), field string) {
+	section := benchPB(b).(basedPB).base().votesSection
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for v := range section.Lines {
+			if _, ok := cell(section, v, field); !ok {
+				b.Fatal("Missing field " + field)
+			}
+		}
+	}
+}
+
+func BenchmarkCell(b *testing.B) {
+	benchCells(b, (*Section).Cell, "age")
+}
+
+func BenchmarkCellBaseline(b *testing.B) {
+	benchCells(b, baselineCell, "age")
+}
+
+func benchTallyCells(b *testing.B, cell func(*Section, int, string) (string, bool)) {
+	section := benchPB(b).(basedPB).base().votesSection
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		count := make(map[string]int, benchProjects)
+		for v := range section.Lines {
+			vote, _ := cell(section, v, "vote")
+			for _, project := range splitList(vote) {
+				count[project] += 1
+			}
+		}
+	}
+}
+
+func BenchmarkTallyCells(b *testing.B) {
+	benchTallyCells(b, (*Section).Cell)
+}
+
+func BenchmarkTallyCellsBaseline(b *testing.B) {
+	benchTallyCells(b, baselineCell)
+}
+
+func BenchmarkMetaBaseline(b *testing.B) {
+	section := benchPB(b).(basedPB).base().metaSection
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		baselineMetaInt(section, "max_length", benchProjects)
+		baselineMetaInt(section, "min_length", 1)
+		baselineMetaInt(section, "max_sum_cost", 0)
+	}
+}
+
+func BenchmarkGreedy(b *testing.B) {
+	pb := benchPB(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Greedy(pb, GreedyOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCompile(b *testing.B) {
+	pb := benchPB(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Compile(pb); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCompiledTally(b *testing.B) {
+	compiled, err := Compile(benchPB(b))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		count := make([]int, len(compiled.ProjectIds))
+		for _, set := range compiled.Approvals {
+			for _, project := range set.Indexes() {
+				count[project] += 1
+			}
+		}
+	}
+}
+
+func BenchmarkOpenParallel(b *testing.B) {
+	data := largeFile()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := OpenParallel(context.Background(), strings.NewReader(data), ParallelOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
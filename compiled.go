@@ -0,0 +1,273 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+)
+
+var (
+	Int32Overflow = errors.New("Value does not fit in 32 bits")
+)
+
+// Bitset is a set of project indexes.
+type Bitset []uint64
+
+func newBitset(size int) Bitset {
+	return make(Bitset, (size+63)/64)
+}
+
+func (self Bitset) Has(index int) bool {
+	return self[index/64]&(1<<(uint(index)%64)) != 0
+}
+
+func (self Bitset) set(index int) {
+	self[index/64] |= 1 << (uint(index) % 64)
+}
+
+// Count returns the number of elements of the set.
+func (self Bitset) Count() (ret int) {
+	for _, word := range self {
+		ret += bits.OnesCount64(word)
+	}
+	return
+}
+
+// Indexes returns the elements of the set, in increasing order.
+func (self Bitset) Indexes() []int {
+	ret := make([]int, 0, self.Count())
+	for i, word := range self {
+		for word != 0 {
+			ret = append(ret, i*64+bits.TrailingZeros64(word))
+			word &= word - 1
+		}
+	}
+	return ret
+}
+
+// Column holds the values of a field of the votes, dictionary encoded.
+type Column struct {
+	Name string
+	// The distinct values, in order of first appearance. A missing field is
+	// stored as the empty string.
+	Values []string
+	// The index in Values of the value of each voter.
+	Codes []int32
+}
+
+// Value returns the value of the field for the voter at given index.
+func (self *Column) Value(voter int) string {
+	return self.Values[self.Codes[voter]]
+}
+
+// Compiled is a compact representation of a PB, for computations on integers.
+// Projects and voters are identified by their index in the PB. Projects unknown
+// to the PB are ignored, and only the first occurrence of a project in a vote
+// is kept.
+type Compiled struct {
+	VoteType int
+	Budget   int
+	// The identifier and the cost of each project.
+	ProjectIds []string
+	Costs      []int
+	// The identifier of each voter.
+	VoterIds []string
+
+	// The approved projects of each voter, for approval votes. All bitsets share
+	// the same backing array.
+	Approvals []Bitset
+
+	// The projects of each voter, for other vote types, are
+	// Projects[Offsets[v]:Offsets[v+1]], in file order. Offsets has one more
+	// element than the number of voters.
+	Offsets  []int
+	Projects []int32
+	// The value associated with each element of Projects: its position in the
+	// ranking starting at 0 for ordinal votes, and the points given to it for
+	// cumulative and scoring votes.
+	Values []int32
+	// The score of projects not in a scoring vote.
+	DefaultScore int
+
+	// The other fields of the votes, in file order.
+	Columns []Column
+}
+
+// Compile builds the compact representation of the PB. UnsupportedPB is
+// returned if the votes are not of one of the types of this package, or not of
+// the vote type of the PB. Int32Overflow is returned if there are too many
+// projects, or if some points do not fit in an int32.
+func Compile(pb PB) (ret *Compiled, err error) {
+	numVoters := pb.CountVotes()
+	ret = &Compiled{
+		VoteType:   pb.VoteType(),
+		Budget:     pb.Budget(),
+		ProjectIds: projectIds(pb),
+		Costs:      projectCosts(pb),
+		VoterIds:   make([]string, numVoters),
+	}
+	if len(ret.ProjectIds) > math.MaxInt32 {
+		return nil, Int32Overflow
+	}
+	index := make(map[string]int, len(ret.ProjectIds))
+	for i, id := range ret.ProjectIds {
+		if _, dup := index[id]; !dup {
+			index[id] = i
+		}
+	}
+	if scoring, ok := pb.(ScoringPB); ok {
+		ret.DefaultScore = scoring.DefaultScore()
+	}
+
+	approval := ret.VoteType == VoteTypeApproval
+	if approval {
+		ret.Approvals = make([]Bitset, numVoters)
+		words := len(newBitset(len(ret.ProjectIds)))
+		backing := make(Bitset, numVoters*words)
+		for voter := range ret.Approvals {
+			ret.Approvals[voter] = backing[voter*words : (voter+1)*words : (voter+1)*words]
+		}
+	} else {
+		ret.Offsets = make([]int, 1, numVoters+1)
+	}
+
+	seen := newBitset(len(ret.ProjectIds))
+	overflow := false
+	add := func(id string, value int) {
+		project, ok := index[id]
+		if !ok || seen.Has(project) {
+			return
+		}
+		if value < math.MinInt32 || value > math.MaxInt32 {
+			overflow = true
+			return
+		}
+		seen.set(project)
+		ret.Projects = append(ret.Projects, int32(project))
+		ret.Values = append(ret.Values, int32(value))
+	}
+
+	for voter := 0; voter < numVoters; voter++ {
+		vote := pb.Vote(voter)
+		ret.VoterIds[voter] = vote.Id()
+		if _, ok := vote.(ApprovalVote); ok != approval {
+			return nil, UnsupportedPB
+		}
+		switch vote := vote.(type) {
+		case ApprovalVote:
+			for _, id := range vote.Vote {
+				if project, ok := index[id]; ok {
+					ret.Approvals[voter].set(project)
+				}
+			}
+			continue
+		case OrdinalVote:
+			for pos, id := range vote.Vote {
+				add(id, pos)
+			}
+		case CumulativeVote:
+			for _, pp := range vote.Vote {
+				add(pp.Project, pp.Points)
+			}
+		case ScoringVote:
+			for _, pp := range vote.Vote {
+				add(pp.Project, pp.Points)
+			}
+		default:
+			return nil, UnsupportedPB
+		}
+		if overflow {
+			return nil, Int32Overflow
+		}
+		for _, project := range ret.Projects[ret.Offsets[voter]:] {
+			seen[project/64] = 0
+		}
+		ret.Offsets = append(ret.Offsets, len(ret.Projects))
+	}
+
+	if based, ok := pb.(basedPB); ok {
+		ret.Columns = compileColumns(based.base().votesSection)
+	}
+	return
+}
+
+// compileColumns dictionary encodes the fields of the votes other than the
+// identifier, the vote and the points.
+func compileColumns(section *Section) (ret []Column) {
+	for i, field := range section.Fields {
+		switch field {
+		case "voter_id", "vote", "points":
+			continue
+		}
+		if first, _ := section.fieldIndex(field); first != i {
+			// Duplicated field.
+			continue
+		}
+		column := Column{Name: field, Codes: make([]int32, len(section.Lines))}
+		codes := make(map[string]int32)
+		for voter, line := range section.Lines {
+			value := ""
+			if i < len(line) {
+				value = line[i]
+			}
+			code, ok := codes[value]
+			if !ok {
+				code = int32(len(column.Values))
+				codes[value] = code
+				column.Values = append(column.Values, value)
+			}
+			column.Codes[voter] = code
+		}
+		ret = append(ret, column)
+	}
+	return
+}
+
+// NumVoters returns the number of votes.
+func (self *Compiled) NumVoters() int {
+	return len(self.VoterIds)
+}
+
+// Entries returns the projects of the voter at given index and their values,
+// for vote types other than approval.
+func (self *Compiled) Entries(voter int) (projects, values []int32) {
+	begin, end := self.Offsets[voter], self.Offsets[voter+1]
+	return self.Projects[begin:end], self.Values[begin:end]
+}
+
+// Score returns the score given by a voter to a project, for scoring votes.
+func (self *Compiled) Score(voter, project int) int {
+	projects, values := self.Entries(voter)
+	for i, p := range projects {
+		if int(p) == project {
+			return int(values[i])
+		}
+	}
+	return self.DefaultScore
+}
+
+// Column returns the column of the field with given name.
+func (self *Compiled) Column(name string) (*Column, bool) {
+	for i := range self.Columns {
+		if self.Columns[i].Name == name {
+			return &self.Columns[i], true
+		}
+	}
+	return nil, false
+}
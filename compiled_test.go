@@ -0,0 +1,155 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBitset(t *testing.T) {
+	set := newBitset(130)
+	for _, index := range []int{0, 63, 64, 129} {
+		set.set(index)
+	}
+	if got := set.Count(); got != 4 {
+		t.Errorf("Wrong count. Got %d. Expect 4.", got)
+	}
+	if set.Has(1) || !set.Has(64) {
+		t.Errorf("Wrong membership.")
+	}
+	if got, expect := set.Indexes(), []int{0, 63, 64, 129}; !reflect.DeepEqual(got, expect) {
+		t.Errorf("Wrong indexes. Got %v. Expect %v.", got, expect)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		approvals [][]int
+		offsets   []int
+		projects  []int32
+		values    []int32
+		err       error
+	}{
+		{
+			name:      "Approval",
+			data:      makeRuleData("approval", 100, []string{"a:10", "b:20", "c:30"}, [][]string{{"a,c"}, {""}, {"b,x,b"}}),
+			approvals: [][]int{{0, 2}, {}, {1}},
+		},
+		{
+			name:     "Ordinal",
+			data:     makeRuleData("ordinal", 100, []string{"a:10", "b:20", "c:30"}, [][]string{{"c,a"}, {"b,x,c,b"}}),
+			offsets:  []int{0, 2, 4},
+			projects: []int32{2, 0, 1, 2},
+			values:   []int32{0, 1, 0, 2},
+		},
+		{
+			name:     "Cumulative",
+			data:     makeRuleData("cumulative", 100, []string{"a:10", "b:20"}, [][]string{{"b,a", "3,1"}, {"", ""}}),
+			offsets:  []int{0, 2, 2},
+			projects: []int32{1, 0},
+			values:   []int32{3, 1},
+		},
+		{
+			name:     "Scoring",
+			data:     makeRuleData("scoring", 100, []string{"a:10", "b:20"}, [][]string{{"b,a", "-2,1"}}),
+			offsets:  []int{0, 2},
+			projects: []int32{1, 0},
+			values:   []int32{-2, 1},
+		},
+		{
+			name: "Overflow",
+			data: makeRuleData("cumulative", 100, []string{"a:10", "b:20"},
+				[][]string{{"a", "1"}, {"b,a", "1,2147483648"}}),
+			err: Int32Overflow,
+		},
+		{
+			name: "Negative overflow",
+			data: makeRuleData("scoring", 100, []string{"a:10", "b:20"}, [][]string{{"a", "-2147483649"}}),
+			err:  Int32Overflow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := Compile(mustOpen(t, tt.data))
+			if tt.err != nil {
+				if err != tt.err {
+					t.Errorf("Got error %v. Expect error %v.", err, tt.err)
+				}
+				return
+			}
+			mustt(t, err)
+			if tt.approvals != nil {
+				got := make([][]int, len(compiled.Approvals))
+				for i, set := range compiled.Approvals {
+					got[i] = set.Indexes()
+				}
+				if !reflect.DeepEqual(got, tt.approvals) {
+					t.Errorf("Wrong approvals. Got %v. Expect %v.", got, tt.approvals)
+				}
+				return
+			}
+			if !reflect.DeepEqual(compiled.Offsets, tt.offsets) {
+				t.Errorf("Wrong offsets. Got %v. Expect %v.", compiled.Offsets, tt.offsets)
+			}
+			if !reflect.DeepEqual(compiled.Projects, tt.projects) {
+				t.Errorf("Wrong projects. Got %v. Expect %v.", compiled.Projects, tt.projects)
+			}
+			if !reflect.DeepEqual(compiled.Values, tt.values) {
+				t.Errorf("Wrong values. Got %v. Expect %v.", compiled.Values, tt.values)
+			}
+		})
+	}
+}
+
+func TestCompile_Columns(t *testing.T) {
+	data := strings.Replace(
+		makeRuleData("scoring", 100, []string{"a:10", "b:20"}, [][]string{{"a", "2"}, {"b", "1"}, {"", ""}}),
+		"voter_id;vote;points\n0;a;2\n1;b;1\n2;;\n",
+		"voter_id;age;vote;points;sex\n0;30;a;2;F\n1;25;b;1;M\n2;30;;;F\n", 1)
+	pb := mustOpen(t, data)
+	compiled, err := Compile(pb)
+	mustt(t, err)
+
+	if got, expect := compiled.VoterIds, []string{"0", "1", "2"}; !reflect.DeepEqual(got, expect) {
+		t.Errorf("Wrong voter ids. Got %v. Expect %v.", got, expect)
+	}
+	expect := []Column{
+		{Name: "age", Values: []string{"30", "25"}, Codes: []int32{0, 1, 0}},
+		{Name: "sex", Values: []string{"F", "M"}, Codes: []int32{0, 1, 0}},
+	}
+	if !reflect.DeepEqual(compiled.Columns, expect) {
+		t.Errorf("Wrong columns. Got %v. Expect %v.", compiled.Columns, expect)
+	}
+	if column, ok := compiled.Column("sex"); !ok || column.Value(1) != "M" {
+		t.Errorf("Wrong sex column %v.", column)
+	}
+	if _, ok := compiled.Column("vote"); ok {
+		t.Errorf("Unexpected vote column.")
+	}
+	for voter := 0; voter < pb.CountVotes(); voter++ {
+		for project := 0; project < pb.CountProjects(); project++ {
+			expect := pb.(ScoringPB).Score(voter, pb.ProjectByIndex(project).Id())
+			if got := compiled.Score(voter, project); got != expect {
+				t.Errorf("Wrong score of %d for %d. Got %d. Expect %d.", voter, project, got, expect)
+			}
+		}
+	}
+}
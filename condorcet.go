@@ -0,0 +1,293 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"sort"
+)
+
+// PairwiseMatrix counts, for each pair of projects given by index, the number
+// of voters preferring the first project to the second one.
+type PairwiseMatrix [][]int
+
+// Pairwise computes the pairwise majority matrix of an ordinal PB. A voter
+// prefers a project to another if the first one is ranked before the second
+// one, or if only the first one is ranked. Only the first MaxLength projects of
+// each ranking are considered. Projects unknown to the PB and repeated
+// projects are ignored.
+func Pairwise(pb PB) (ret PairwiseMatrix, err error) {
+	ordinal, ok := pb.(OrdinalPB)
+	if !ok {
+		return nil, UnsupportedVoteType
+	}
+
+	numProjects := pb.CountProjects()
+	index := make(map[string]int, numProjects)
+	for i, id := range projectIds(pb) {
+		index[id] = i
+	}
+	ret = make(PairwiseMatrix, numProjects)
+	for i := range ret {
+		ret[i] = make([]int, numProjects)
+	}
+
+	maxLength := ordinal.MaxLength()
+	ranked := make([]bool, numProjects)
+	for v := 0; v < pb.CountVotes(); v++ {
+		for i := range ranked {
+			ranked[i] = false
+		}
+		for pos, id := range pb.Vote(v).(OrdinalVote).Vote {
+			if pos >= maxLength {
+				break
+			}
+			project, ok := index[id]
+			if !ok || ranked[project] {
+				continue
+			}
+			ranked[project] = true
+			for other, done := range ranked {
+				if !done {
+					ret[project][other] += 1
+				}
+			}
+		}
+	}
+	return
+}
+
+// Margin returns the number of voters preferring a to b minus the number of
+// voters preferring b to a.
+func (self PairwiseMatrix) Margin(a, b int) int {
+	return self[a][b] - self[b][a]
+}
+
+// CondorcetWinner returns the project beating all other projects by a strict
+// majority, if any.
+func (self PairwiseMatrix) CondorcetWinner() (project int, ok bool) {
+	return self.findExtreme(1)
+}
+
+// CondorcetLoser returns the project beaten by all other projects by a strict
+// majority, if any.
+func (self PairwiseMatrix) CondorcetLoser() (project int, ok bool) {
+	return self.findExtreme(-1)
+}
+
+func (self PairwiseMatrix) findExtreme(sign int) (int, bool) {
+	if len(self) == 0 {
+		return -1, false
+	}
+	for a := range self {
+		ok := true
+		for b := range self {
+			if a != b && sign*self.Margin(a, b) <= 0 {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return a, true
+		}
+	}
+	return -1, false
+}
+
+// Copeland returns the projects by decreasing Copeland score, which is the
+// number of projects they beat minus the number of projects beating them.
+// Ties are ordered as in the file.
+func (self PairwiseMatrix) Copeland() []int {
+	return orderByScore(self.copelandScores())
+}
+
+// Schulze returns the projects ordered by the Schulze method. Ties are ordered
+// as in the file.
+func (self PairwiseMatrix) Schulze() []int {
+	return orderByScore(self.schulzeScores())
+}
+
+// RankedPairs returns the projects ordered by the ranked pairs method. Pairs
+// with the same margin are locked in file order of their first, then second,
+// project. Remaining ties are ordered as in the file.
+func (self PairwiseMatrix) RankedPairs() []int {
+	return orderByScore(self.rankedPairsScores())
+}
+
+func (self PairwiseMatrix) copelandScores() []float64 {
+	ret := make([]float64, len(self))
+	for a := range self {
+		for b := range self {
+			switch margin := self.Margin(a, b); {
+			case margin > 0:
+				ret[a] += 1
+			case margin < 0:
+				ret[a] -= 1
+			}
+		}
+	}
+	return ret
+}
+
+// schulzeScores gives each project the number of projects it beats according
+// to the strongest paths, which is a transitive relation.
+func (self PairwiseMatrix) schulzeScores() []float64 {
+	n := len(self)
+	strength := make([][]int, n)
+	for a := range strength {
+		strength[a] = make([]int, n)
+		for b := range strength[a] {
+			if a != b && self[a][b] > self[b][a] {
+				strength[a][b] = self[a][b]
+			}
+		}
+	}
+	for k := 0; k < n; k++ {
+		for a := 0; a < n; a++ {
+			if a == k {
+				continue
+			}
+			for b := 0; b < n; b++ {
+				if b == a || b == k {
+					continue
+				}
+				if via := minInt(strength[a][k], strength[k][b]); via > strength[a][b] {
+					strength[a][b] = via
+				}
+			}
+		}
+	}
+
+	ret := make([]float64, n)
+	for a := range strength {
+		for b := range strength {
+			if strength[a][b] > strength[b][a] {
+				ret[a] += 1
+			}
+		}
+	}
+	return ret
+}
+
+// rankedPairsScores gives each project the number of projects reachable from
+// it in the graph of locked pairs.
+func (self PairwiseMatrix) rankedPairsScores() []float64 {
+	n := len(self)
+	type pair struct {
+		winner, loser, margin int
+	}
+	var pairs []pair
+	for a := range self {
+		for b := range self {
+			if margin := self.Margin(a, b); margin > 0 {
+				pairs = append(pairs, pair{a, b, margin})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].margin > pairs[j].margin
+	})
+
+	// reach[a][b] is true if b is reachable from a through locked pairs.
+	reach := make([][]bool, n)
+	for a := range reach {
+		reach[a] = make([]bool, n)
+	}
+	for _, p := range pairs {
+		if reach[p.loser][p.winner] || reach[p.winner][p.loser] {
+			continue
+		}
+		for a := 0; a < n; a++ {
+			if a != p.winner && !reach[a][p.winner] {
+				continue
+			}
+			reach[a][p.loser] = true
+			for b := 0; b < n; b++ {
+				if reach[p.loser][b] {
+					reach[a][b] = true
+				}
+			}
+		}
+	}
+
+	ret := make([]float64, n)
+	for a := range reach {
+		for _, ok := range reach[a] {
+			if ok {
+				ret[a] += 1
+			}
+		}
+	}
+	return ret
+}
+
+func orderByScore(scores []float64) []int {
+	ret := make([]int, len(scores))
+	for i := range ret {
+		ret[i] = i
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return scores[ret[i]] > scores[ret[j]]
+	})
+	return ret
+}
+
+// Orderings of the projects based on the pairwise majority matrix.
+const (
+	OrderCopeland = iota
+	OrderSchulze
+	OrderRankedPairs
+)
+
+type CondorcetOptions struct {
+	Order int
+	// How ties are broken. Nil means file order.
+	Ties TieBreaker
+}
+
+// Condorcet computes the outcome of a Condorcet-consistent rule on ordinal
+// votes. Projects are examined in the order given by the options, and each
+// project is funded if it fits in the remaining budget. Projects tied in the
+// order are examined in the order given by the tie breaker. The score of each
+// step of the trace is the Copeland score, the number of projects beaten
+// according to the strongest paths, or the number of projects below in the
+// locked pairs.
+func Condorcet(pb PB, opts CondorcetOptions) (ret Outcome, err error) {
+	matrix, err := Pairwise(pb)
+	if err != nil {
+		return
+	}
+
+	var scores []float64
+	switch opts.Order {
+	case OrderSchulze:
+		scores = matrix.schulzeScores()
+	case OrderRankedPairs:
+		scores = matrix.rankedPairsScores()
+	default:
+		scores = matrix.copelandScores()
+	}
+
+	projects := make([]int, pb.CountProjects())
+	for i := range projects {
+		projects[i] = i
+	}
+	selected, trace := greedyPass(pb, opts.Ties, projects, scores, pb.Budget(), false)
+
+	ret = newOutcome(pb, selected)
+	ret.Trace = trace
+	return
+}
@@ -0,0 +1,155 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"testing"
+)
+
+// cycleVotes have no Condorcet winner. The cycle a > b > c > a is broken at its
+// weakest pair c > a.
+var cycleVotes = [][]string{
+	{"a,b,c"}, {"a,b,c"}, {"a,b,c"},
+	{"b,c,a"}, {"b,c,a"},
+	{"c,a,b"}, {"c,a,b"},
+}
+
+func TestPairwise(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		matrix      PairwiseMatrix
+		winner      int
+		loser       int
+		copeland    []int
+		schulze     []int
+		rankedPairs []int
+	}{
+		{
+			name: "Condorcet winner",
+			data: makeRuleData("ordinal", 100, []string{"a:10", "b:10", "c:10", "d:10"},
+				[][]string{{"a,b,c"}, {"a,c,b,x"}, {"b,a,c,a"}}),
+			matrix:      PairwiseMatrix{{0, 2, 3, 3}, {1, 0, 2, 3}, {0, 1, 0, 3}, {0, 0, 0, 0}},
+			winner:      0,
+			loser:       3,
+			copeland:    []int{0, 1, 2, 3},
+			schulze:     []int{0, 1, 2, 3},
+			rankedPairs: []int{0, 1, 2, 3},
+		},
+		{
+			name:        "Cycle",
+			data:        makeRuleData("ordinal", 100, []string{"c:10", "b:10", "a:10"}, cycleVotes),
+			matrix:      PairwiseMatrix{{0, 2, 4}, {5, 0, 2}, {3, 5, 0}},
+			winner:      -1,
+			loser:       -1,
+			copeland:    []int{0, 1, 2},
+			schulze:     []int{2, 1, 0},
+			rankedPairs: []int{2, 1, 0},
+		},
+		{
+			name: "Condorcet loser",
+			data: makeRuleData("ordinal", 100, []string{"a:10", "b:10", "c:10"},
+				[][]string{{"c,b,a"}, {"a,b,c"}, {"a,c,b"}}),
+			matrix:      PairwiseMatrix{{0, 2, 2}, {1, 0, 1}, {1, 2, 0}},
+			winner:      0,
+			loser:       1,
+			copeland:    []int{0, 2, 1},
+			schulze:     []int{0, 2, 1},
+			rankedPairs: []int{0, 2, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matrix, err := Pairwise(mustOpen(t, tt.data))
+			mustt(t, err)
+
+			if !reflect.DeepEqual(matrix, tt.matrix) {
+				t.Errorf("Wrong matrix. Got %v. Expect %v.", matrix, tt.matrix)
+			}
+			if got, ok := matrix.CondorcetWinner(); got != tt.winner || ok != (tt.winner >= 0) {
+				t.Errorf("Wrong winner. Got %d. Expect %d.", got, tt.winner)
+			}
+			if got, ok := matrix.CondorcetLoser(); got != tt.loser || ok != (tt.loser >= 0) {
+				t.Errorf("Wrong loser. Got %d. Expect %d.", got, tt.loser)
+			}
+			if got := matrix.Copeland(); !reflect.DeepEqual(got, tt.copeland) {
+				t.Errorf("Wrong Copeland order. Got %v. Expect %v.", got, tt.copeland)
+			}
+			if got := matrix.Schulze(); !reflect.DeepEqual(got, tt.schulze) {
+				t.Errorf("Wrong Schulze order. Got %v. Expect %v.", got, tt.schulze)
+			}
+			if got := matrix.RankedPairs(); !reflect.DeepEqual(got, tt.rankedPairs) {
+				t.Errorf("Wrong ranked pairs order. Got %v. Expect %v.", got, tt.rankedPairs)
+			}
+		})
+	}
+}
+
+func TestCondorcet(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		opts     CondorcetOptions
+		selected []string
+		err      error
+	}{
+		{
+			name:     "Copeland",
+			data:     makeRuleData("ordinal", 60, []string{"c:30", "b:30", "a:30"}, cycleVotes),
+			selected: []string{"c", "b"},
+		},
+		{
+			name:     "Copeland with ties",
+			data:     makeRuleData("ordinal", 60, []string{"c:30", "b:30", "a:30"}, cycleVotes),
+			opts:     CondorcetOptions{Ties: TieLexicographic},
+			selected: []string{"a", "b"},
+		},
+		{
+			name:     "Schulze",
+			data:     makeRuleData("ordinal", 60, []string{"c:30", "b:40", "a:30"}, cycleVotes),
+			opts:     CondorcetOptions{Order: OrderSchulze},
+			selected: []string{"a", "c"},
+		},
+		{
+			name:     "Ranked pairs",
+			data:     makeRuleData("ordinal", 60, []string{"c:30", "b:30", "a:30"}, cycleVotes),
+			opts:     CondorcetOptions{Order: OrderRankedPairs},
+			selected: []string{"a", "b"},
+		},
+		{
+			name: "Approval",
+			data: makeRuleData("approval", 60, []string{"a:30"}, [][]string{{"a"}}),
+			err:  UnsupportedVoteType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, err := Condorcet(mustOpen(t, tt.data), tt.opts)
+			if tt.err != nil {
+				if err != tt.err {
+					t.Errorf("Got error %v. Expect error %v.", err, tt.err)
+				}
+				return
+			}
+			mustt(t, err)
+			if !reflect.DeepEqual(outcome.Selected, tt.selected) {
+				t.Errorf("Wrong selection. Got %v. Expect %v.", outcome.Selected, tt.selected)
+			}
+		})
+	}
+}
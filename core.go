@@ -0,0 +1,248 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultMaxCoreSets is the default value of CoreOptions.MaxSets.
+const DefaultMaxCoreSets = 1 << 20
+
+type CoreOptions struct {
+	// The approximation factor. Values less than 1 mean 1, which is the core.
+	Alpha float64
+	// The maximal number of sets of projects to examine. Zero means
+	// DefaultMaxCoreSets.
+	MaxSets int
+}
+
+type CoreReport struct {
+	// Whether no blocking set of projects has been found.
+	InCore bool
+	// Whether all the sets of projects fitting in the budget have been
+	// examined, in which case InCore is certain.
+	Exact bool
+	// The voters and projects of a blocking set, if any has been found.
+	Witness *Witness
+	// The largest factor for which a blocking set has been found: the outcome
+	// is not in the α-core for any α less than Bound. When Exact is true, the
+	// outcome is in the α-core for all α at least Bound. It is +Inf if a group
+	// gets nothing from the outcome and can pay for projects they like.
+	Bound float64
+}
+
+// Core checks whether the given projects are in the α-core. A set T of
+// projects blocks the outcome if the voters getting from T more than α times
+// the utility they get from the outcome, can pay for T with their share of the
+// budget. The outcome is in the α-core if no set blocks it. Utilities are the
+// ones given by the votes, as for the utilitarian rule.
+//
+// Sets of projects are examined by increasing size, stopping after MaxSets
+// sets. Small instances are hence checked exactly, while for larger ones the
+// result is exact only if a blocking set is found, and Bound is a lower bound
+// of the best approximation factor. An UnknownProject error is returned if a
+// selected project is not in the PB.
+func Core(pb PB, selected []string, opts CoreOptions) (ret CoreReport, err error) {
+	ballots, err := profile(pb)
+	if err != nil {
+		return
+	}
+	isSelected, err := selectedIndexes(pb, selected)
+	if err != nil {
+		return
+	}
+
+	search := coreSearch{
+		budget:     pb.Budget(),
+		numVoters:  len(ballots),
+		costs:      projectCosts(pb),
+		supporters: make([][]coreSupporter, pb.CountProjects()),
+		outcome:    make([]float64, len(ballots)),
+		utility:    make([]float64, len(ballots)),
+		stamp:      make([]int, len(ballots)),
+		maxSets:    opts.MaxSets,
+	}
+	if search.maxSets <= 0 {
+		search.maxSets = DefaultMaxCoreSets
+	}
+	for voter, b := range ballots {
+		for i, project := range b.projects {
+			utility := b.utilities[i]
+			if utility <= 0 {
+				continue
+			}
+			search.supporters[project] = append(search.supporters[project], coreSupporter{voter, utility})
+			if isSelected[project] {
+				search.outcome[voter] += utility
+			}
+		}
+	}
+
+	ret.Exact = true
+	for size := 1; size <= len(search.costs); size++ {
+		before := search.sets
+		if !search.enumerate(size, 0, 0) {
+			ret.Exact = false
+			break
+		}
+		if search.sets == before {
+			// Sets of that size do not fit in the budget, nor larger ones.
+			break
+		}
+	}
+
+	alpha := math.Max(1, opts.Alpha)
+	ret.Bound = search.bound
+	ret.InCore = search.bound <= alpha
+	if !ret.InCore {
+		for _, project := range search.best {
+			search.add(project)
+		}
+		voters, ratios := search.ratios()
+		var group []int
+		for i, voter := range voters {
+			if ratios[i] > alpha {
+				group = append(group, voter)
+			}
+		}
+		sort.Ints(group)
+
+		ret.Witness = &Witness{Voters: make([]string, len(group)), Projects: make([]string, len(search.best))}
+		for i, voter := range group {
+			ret.Witness.Voters[i] = pb.Vote(voter).Id()
+		}
+		for i, project := range search.best {
+			ret.Witness.Projects[i] = pb.ProjectByIndex(project).Id()
+		}
+	}
+	return
+}
+
+type coreSupporter struct {
+	voter   int
+	utility float64
+}
+
+type coreSearch struct {
+	budget     int
+	numVoters  int
+	costs      []int
+	supporters [][]coreSupporter
+	// The utility of each voter for the outcome.
+	outcome []float64
+	// The utility of each voter for the current set.
+	utility []float64
+	current []int
+	// Used to list each voter once. A voter has been listed by the current call
+	// to ratios if its stamp is round.
+	stamp []int
+	round int
+	sets  int
+
+	maxSets int
+	bound   float64
+	best    []int
+}
+
+// enumerate examines the sets of the given size extending the current one
+// with projects from index first. It returns false if a set remains to be
+// examined once the maximal number of sets has been reached.
+func (self *coreSearch) enumerate(size, first, cost int) bool {
+	if len(self.current) == size {
+		if self.sets == self.maxSets {
+			return false
+		}
+		self.sets += 1
+		self.evaluate(cost)
+		return true
+	}
+	for project := first; project < len(self.costs); project++ {
+		if cost+self.costs[project] > self.budget {
+			continue
+		}
+		self.add(project)
+		ok := self.enumerate(size, project+1, cost+self.costs[project])
+		self.remove(project)
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (self *coreSearch) add(project int) {
+	self.current = append(self.current, project)
+	for _, s := range self.supporters[project] {
+		self.utility[s.voter] += s.utility
+	}
+}
+
+func (self *coreSearch) remove(project int) {
+	self.current = self.current[:len(self.current)-1]
+	for _, s := range self.supporters[project] {
+		self.utility[s.voter] -= s.utility
+	}
+}
+
+// ratios returns the voters having a positive utility for the current set,
+// and the ratio of that utility over their utility for the outcome.
+func (self *coreSearch) ratios() (voters []int, ratios []float64) {
+	self.round += 1
+	for _, project := range self.current {
+		for _, s := range self.supporters[project] {
+			if self.stamp[s.voter] == self.round {
+				continue
+			}
+			self.stamp[s.voter] = self.round
+			voters = append(voters, s.voter)
+			if self.outcome[s.voter] > 0 {
+				ratios = append(ratios, self.utility[s.voter]/self.outcome[s.voter])
+			} else {
+				ratios = append(ratios, math.Inf(1))
+			}
+		}
+	}
+	return
+}
+
+// evaluate updates the bound with the largest factor for which the current
+// set blocks the outcome, which is the ratio of the voter with the smallest
+// ratio in the best group able to pay for the set.
+func (self *coreSearch) evaluate(cost int) {
+	need := 1
+	if cost > 0 {
+		if self.budget == 0 {
+			return
+		}
+		need = (cost*self.numVoters + self.budget - 1) / self.budget
+		if need < 1 {
+			need = 1
+		}
+	}
+
+	_, ratios := self.ratios()
+	if len(ratios) < need {
+		return
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(ratios)))
+	if factor := ratios[need-1]; factor > self.bound {
+		self.bound = factor
+		self.best = append(self.best[:0], self.current...)
+	}
+}
@@ -0,0 +1,104 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCore(t *testing.T) {
+	// Voters 0 and 1 can pay for both a and b.
+	data := makeRuleData("approval", 200, []string{"a:50", "b:50", "c:100"},
+		[][]string{{"a,b"}, {"a,b"}, {"c"}, {"c"}})
+	tests := []struct {
+		name     string
+		selected []string
+		opts     CoreOptions
+		expect   CoreReport
+		err      error
+	}{
+		{
+			name:     "Blocked",
+			selected: []string{"a", "c"},
+			expect: CoreReport{
+				Exact:   true,
+				Bound:   2,
+				Witness: &Witness{Voters: []string{"0", "1"}, Projects: []string{"a", "b"}},
+			},
+		},
+		{
+			name:     "Approximate core",
+			selected: []string{"a", "c"},
+			opts:     CoreOptions{Alpha: 2},
+			expect:   CoreReport{InCore: true, Exact: true, Bound: 2},
+		},
+		{
+			name:     "In core",
+			selected: []string{"a", "b", "c"},
+			expect:   CoreReport{InCore: true, Exact: true, Bound: 1},
+		},
+		{
+			name:     "Nothing",
+			selected: []string{"a", "b"},
+			expect: CoreReport{
+				Exact:   true,
+				Bound:   math.Inf(1),
+				Witness: &Witness{Voters: []string{"2", "3"}, Projects: []string{"c"}},
+			},
+		},
+		{
+			name:     "Limited",
+			selected: []string{"a", "c"},
+			opts:     CoreOptions{MaxSets: 2},
+			expect:   CoreReport{InCore: true, Bound: 1},
+		},
+		{
+			name:     "All sets at the limit",
+			selected: []string{"a", "b", "c"},
+			opts:     CoreOptions{MaxSets: 7},
+			expect:   CoreReport{InCore: true, Exact: true, Bound: 1},
+		},
+		{
+			name:     "One set over the limit",
+			selected: []string{"a", "b", "c"},
+			opts:     CoreOptions{MaxSets: 6},
+			expect:   CoreReport{InCore: true, Bound: 1},
+		},
+		{
+			name:     "Unknown project",
+			selected: []string{"x"},
+			err:      UnknownProject{"x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Core(mustOpen(t, data), tt.selected, tt.opts)
+			if tt.err != nil {
+				if err != tt.err {
+					t.Errorf("Got error %v. Expect error %v.", err, tt.err)
+				}
+				return
+			}
+			mustt(t, err)
+			if !reflect.DeepEqual(report, tt.expect) {
+				t.Errorf("Got %+v (%+v). Expect %+v (%+v).", report, report.Witness, tt.expect, tt.expect.Witness)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"strconv"
+)
+
+type ProjectPoints struct {
+	Project string
+	Points  int
+}
+
+type CumulativeVote struct {
+	// The voted projects with the points given to them, in file order.
+	Vote []ProjectPoints
+
+	voteBase
+}
+
+type CumulativePB struct {
+	*pbBase
+}
+
+// mustPoints pairs the projects of the vote field with the values of the points
+// field. Malformed values, which make it panic, are rejected by
+// pbBase.checkPoints.
+func (self voteBase) mustPoints() []ProjectPoints {
+	projects := self.mustList("vote")
+	points := self.mustList("points")
+	if len(projects) != len(points) {
+		panic(WrongFormat)
+	}
+
+	ret := make([]ProjectPoints, len(projects))
+	for i, project := range projects {
+		value, err := strconv.Atoi(points[i])
+		if err != nil {
+			panic(err)
+		}
+		ret[i] = ProjectPoints{Project: project, Points: value}
+	}
+	return ret
+}
+
+func newCumulativeVote(base voteBase) (ret CumulativeVote) {
+	ret.voteBase = base
+	ret.Vote = ret.mustPoints()
+	return
+}
+
+func newCumulativePB(file *File) (ret CumulativePB, err error) {
+	ret = CumulativePB{}
+	if ret.pbBase, err = newPbBase(file); err != nil {
+		return
+	}
+	err = ret.checkPoints()
+	return
+}
+
+func (self CumulativePB) Vote(index int) Vote {
+	return newCumulativeVote(newVoteBase(self.votesSection, index))
+}
+
+func (self CumulativePB) MinPoints() int {
+	return self.defaultMetaInt("min_points", 0)
+}
+
+func (self CumulativePB) MaxPoints() int {
+	return self.defaultMetaInt("max_points", self.MaxSumPoints())
+}
+
+func (self CumulativePB) MinSumPoints() int {
+	return self.defaultMetaInt("min_sum_points", 0)
+}
+
+// MaxSumPoints returns the maximal total of points of each vote. There is no
+// such limit when the meta key is absent, in which case the maximal int value
+// is returned.
+func (self CumulativePB) MaxSumPoints() int {
+	return self.defaultMetaInt("max_sum_points", maxInt)
+}
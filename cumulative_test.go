@@ -0,0 +1,170 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"testing"
+)
+
+type cumulativeVoteRepr struct {
+	id   string
+	vote []ProjectPoints
+}
+
+func TestCumulativePB(t *testing.T) {
+	tests := []struct {
+		name         string
+		repr         []namedSection
+		minPoints    int
+		maxPoints    int
+		minSumPoints int
+		maxSumPoints int
+		votes        []cumulativeVoteRepr
+	}{
+		{
+			name: "full",
+			repr: []namedSection{
+				{
+					name: "META",
+					section: Section{
+						Fields: []string{"key", "value"},
+						Lines: [][]string{
+							{"num_projects", "3"},
+							{"num_votes", "2"},
+							{"budget", "1000"},
+							{"vote_type", "cumulative"},
+							{"min_points", "1"},
+							{"max_points", "5"},
+							{"min_sum_points", "2"},
+							{"max_sum_points", "10"},
+							{"rule", "greedy"},
+						},
+					},
+				},
+				{
+					name: "PROJECTS",
+					section: Section{
+						Fields: []string{"project_id", "cost"},
+						Lines: [][]string{
+							{"1", "999"},
+							{"2", "500"},
+							{"3", "200"},
+						},
+					},
+				},
+				{
+					name: "VOTES",
+					section: Section{
+						Fields: []string{"voter_id", "vote", "points"},
+						Lines: [][]string{
+							{"0", "1,2", "5,5"},
+							{"1", "3, 1", "2, 1"},
+						},
+					},
+				},
+			},
+			minPoints:    1,
+			maxPoints:    5,
+			minSumPoints: 2,
+			maxSumPoints: 10,
+			votes: []cumulativeVoteRepr{
+				{id: "0", vote: []ProjectPoints{{"1", 5}, {"2", 5}}},
+				{id: "1", vote: []ProjectPoints{{"3", 2}, {"1", 1}}},
+			},
+		},
+		{
+			name: "default",
+			repr: []namedSection{
+				{
+					name: "META",
+					section: Section{
+						Fields: []string{"key", "value"},
+						Lines: [][]string{
+							{"num_projects", "2"},
+							{"num_votes", "1"},
+							{"budget", "1000"},
+							{"vote_type", "cumulative"},
+							{"max_sum_points", "8"},
+							{"rule", "greedy"},
+						},
+					},
+				},
+				{
+					name: "PROJECTS",
+					section: Section{
+						Fields: []string{"project_id", "cost"},
+						Lines: [][]string{
+							{"1", "999"},
+							{"2", "500"},
+						},
+					},
+				},
+				{
+					name: "VOTES",
+					section: Section{
+						Fields: []string{"voter_id", "vote", "points"},
+						Lines: [][]string{
+							{"0", "2", "8"},
+						},
+					},
+				},
+			},
+			minPoints:    0,
+			maxPoints:    8,
+			minSumPoints: 0,
+			maxSumPoints: 8,
+			votes: []cumulativeVoteRepr{
+				{id: "0", vote: []ProjectPoints{{"2", 8}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pb, err := newCumulativePB(makeFile(tt.repr))
+			mustt(t, err)
+
+			if got := pb.MinPoints(); got != tt.minPoints {
+				t.Errorf("Wrong MinPoints. Got %d. Expect %d.", got, tt.minPoints)
+			}
+			if got := pb.MaxPoints(); got != tt.maxPoints {
+				t.Errorf("Wrong MaxPoints. Got %d. Expect %d.", got, tt.maxPoints)
+			}
+			if got := pb.MinSumPoints(); got != tt.minSumPoints {
+				t.Errorf("Wrong MinSumPoints. Got %d. Expect %d.", got, tt.minSumPoints)
+			}
+			if got := pb.MaxSumPoints(); got != tt.maxSumPoints {
+				t.Errorf("Wrong MaxSumPoints. Got %d. Expect %d.", got, tt.maxSumPoints)
+			}
+
+			for i, expect := range tt.votes {
+				vote, ok := pb.Vote(i).(CumulativeVote)
+				if !ok {
+					t.Errorf("Vote %v not of type CumulativeVote", pb.Vote(i))
+					continue
+				}
+				if got := vote.Id(); got != expect.id {
+					t.Errorf("Wrong Id for vote %d. Got %s. Expect %s.", i, got, expect.id)
+				}
+				if !reflect.DeepEqual(vote.Vote, expect.vote) {
+					t.Errorf("Wrong Vote for vote %d. Got %v. Expect %v.", i, vote.Vote, expect.vote)
+				}
+			}
+		})
+	}
+}
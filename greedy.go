@@ -0,0 +1,118 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"math"
+	"sort"
+)
+
+type GreedyOptions struct {
+	// Stop at the first project that does not fit in the remaining budget,
+	// instead of skipping it and examining the next ones.
+	StopOnOverflow bool
+	// Examine projects by decreasing support per unit of cost. Projects costing
+	// nothing come first.
+	PerCost bool
+	// How ties are broken. Nil means file order.
+	Ties TieBreaker
+}
+
+// Greedy computes the outcome of the greedy rule. Projects are examined by
+// decreasing total support, which is the number of approvals, the sum of the
+// points or the sum of the ordinal scores depending on the vote type. Each
+// project is funded if it fits in the remaining budget. Ties are broken by
+// the tie breaker of the options. The trace contains one step per examined
+// project, whose score is the support, divided by the cost if PerCost is set.
+func Greedy(pb PB, opts GreedyOptions) (ret Outcome, err error) {
+	ballots, err := profile(pb)
+	if err != nil {
+		return
+	}
+	scores := totalUtilities(pb.CountProjects(), ballots)
+	if opts.PerCost {
+		for i, cost := range projectCosts(pb) {
+			if cost > 0 {
+				scores[i] /= float64(cost)
+			} else {
+				scores[i] = math.Inf(1)
+			}
+		}
+	}
+
+	projects := make([]int, pb.CountProjects())
+	for i := range projects {
+		projects[i] = i
+	}
+	selected, trace := greedyPass(pb, opts.Ties, projects, scores, pb.Budget(), opts.StopOnOverflow)
+
+	ret = newOutcome(pb, selected)
+	ret.Trace = trace
+	return
+}
+
+// greedyPass examines the given projects, which must be in file order, by
+// decreasing score and selects those fitting in the remaining budget. Projects
+// with the same score are examined in the order given by the tie breaker.
+func greedyPass(pb PB, ties TieBreaker, projects []int, scores []float64, remaining int, stop bool) (
+	selected []int, trace []Step,
+) {
+	order := append([]int(nil), projects...)
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+
+	for start := 0; start < len(order); {
+		score := scores[order[start]]
+		end := start + 1
+		for end < len(order) && (scores[order[end]] == score || sameWelfare(scores[order[end]], score)) {
+			end += 1
+		}
+		group := append([]int(nil), order[start:end]...)
+		sort.Ints(group)
+		start = end
+
+		for len(group) > 0 {
+			project := breakTie(pb, ties, group)
+			for i := range group {
+				if group[i] == project {
+					group = append(group[:i], group[i+1:]...)
+					break
+				}
+			}
+
+			cost := pb.ProjectByIndex(project).Cost()
+			step := Step{
+				Project:  pb.ProjectByIndex(project).Id(),
+				Score:    scores[project],
+				Cost:     cost,
+				Selected: cost <= remaining,
+			}
+			if step.Selected {
+				remaining -= cost
+				selected = append(selected, project)
+			}
+			step.Remaining = remaining
+			trace = append(trace, step)
+
+			if !step.Selected && stop {
+				return
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,108 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// makeRuleData builds a pabulib file with projects given as id:cost and votes
+// given as raw vote fields (and points fields, for cumulative and scoring).
+func makeRuleData(voteType string, budget int, projects []string, votes [][]string) string {
+	var data strings.Builder
+	fmt.Fprintf(&data, "META\nkey;value\nnum_projects;%d\nnum_votes;%d\nbudget;%d\nvote_type;%s\nrule;greedy\n",
+		len(projects), len(votes), budget, voteType)
+	data.WriteString("PROJECTS\nproject_id;cost\n")
+	for _, project := range projects {
+		data.WriteString(strings.Replace(project, ":", ";", 1) + "\n")
+	}
+	if len(votes) > 0 && len(votes[0]) > 1 {
+		data.WriteString("VOTES\nvoter_id;vote;points\n")
+	} else {
+		data.WriteString("VOTES\nvoter_id;vote\n")
+	}
+	for i, vote := range votes {
+		fmt.Fprintf(&data, "%d;%s\n", i, strings.Join(vote, ";"))
+	}
+	return data.String()
+}
+
+func mustOpen(t *testing.T, data string) PB {
+	pb, err := Open(strings.NewReader(data))
+	mustt(t, err)
+	return pb
+}
+
+func TestGreedy(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		opts     GreedyOptions
+		selected []string
+		trace    []string
+	}{
+		{
+			name: "Approval",
+			data: makeRuleData("approval", 1000, []string{"a:600", "b:500", "c:300", "d:100"},
+				[][]string{{"a,b"}, {"a,c"}, {"b"}, {"b,d"}, {"c"}, {"a"}}),
+			selected: []string{"a", "c", "d"},
+			trace:    []string{"a", "b", "c", "d"},
+		},
+		{
+			name: "Stop on overflow",
+			data: makeRuleData("approval", 1000, []string{"a:600", "b:500", "c:300", "d:100"},
+				[][]string{{"a,b"}, {"a,c"}, {"b"}, {"b,d"}, {"c"}, {"a"}}),
+			opts:     GreedyOptions{StopOnOverflow: true},
+			selected: []string{"a"},
+			trace:    []string{"a", "b"},
+		},
+		{
+			name: "Cumulative",
+			data: makeRuleData("cumulative", 10, []string{"a:5", "b:5", "c:5"},
+				[][]string{{"a,b", "1,4"}, {"c,a", "2,3"}}),
+			selected: []string{"a", "b"},
+			trace:    []string{"a", "b", "c"},
+		},
+		{
+			name: "Ordinal",
+			data: makeRuleData("ordinal", 10, []string{"a:5", "b:5", "c:5"},
+				[][]string{{"c,a"}, {"b,c"}, {"c"}}),
+			selected: []string{"c", "b"},
+			trace:    []string{"c", "b", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, err := Greedy(mustOpen(t, tt.data), tt.opts)
+			mustt(t, err)
+
+			if !reflect.DeepEqual(outcome.Selected, tt.selected) {
+				t.Errorf("Wrong selection. Got %v. Expect %v.", outcome.Selected, tt.selected)
+			}
+			trace := make([]string, len(outcome.Trace))
+			for i, step := range outcome.Trace {
+				trace[i] = step.Project
+			}
+			if !reflect.DeepEqual(trace, tt.trace) {
+				t.Errorf("Wrong trace. Got %v. Expect %v.", trace, tt.trace)
+			}
+		})
+	}
+}
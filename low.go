@@ -18,7 +18,9 @@ package pabulib
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"regexp"
 	"strings"
@@ -31,15 +33,143 @@ var (
 type Section struct {
 	Fields []string
 	Lines  [][]string
+
+	// The index of the first occurrence of each field, for sections created by
+	// NewSection.
+	index map[string]int
+	// The 1-based number of the line where each record starts, for sections
+	// read from a file.
+	lineNums []int
+}
+
+// NewSection creates a section whose fields are indexed, making lookups
+// faster. Fields must not be modified afterwards, except that renamed fields
+// are looked up by scanning.
+func NewSection(fields []string, lines [][]string) *Section {
+	ret := &Section{Fields: fields, Lines: lines, index: make(map[string]int, len(fields))}
+	for i, field := range fields {
+		if _, dup := ret.index[field]; !dup {
+			ret.index[field] = i
+		}
+	}
+	return ret
+}
+
+// fieldIndex returns the index of the first occurrence of the field.
+func (self *Section) fieldIndex(field string) (int, bool) {
+	if i, ok := self.index[field]; ok && i < len(self.Fields) && self.Fields[i] == field {
+		return i, true
+	}
+	for i, f := range self.Fields {
+		if f == field {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// lineNum returns the 1-based number of the line where the record at given
+// index starts, or zero if it is unknown.
+func (self *Section) lineNum(record int) int {
+	if self == nil || record < 0 || record >= len(self.lineNums) {
+		return 0
+	}
+	return self.lineNums[record]
 }
 
 type File struct {
 	sections map[string]*Section
 }
 
+// ParseError reports a malformed record. It matches WrongFormat with
+// errors.Is.
+type ParseError struct {
+	Section string
+	// The 1-based number of the line where the record starts.
+	Line int
+	// The expected and actual number of fields. Expected is zero when the
+	// error is not related to the number of fields.
+	Expected int
+	Got      int
+	// The raw record, possibly spanning several lines.
+	Raw string
+}
+
+func (self ParseError) Error() string {
+	if self.Expected > 0 {
+		return fmt.Sprintf("Wrong format in section %s at line %d: expected %d fields, got %d",
+			self.Section, self.Line, self.Expected, self.Got)
+	}
+	return fmt.Sprintf("Wrong format in section %s at line %d", self.Section, self.Line)
+}
+
+func (self ParseError) Unwrap() error {
+	return WrongFormat
+}
+
+// lineScanner counts the scanned lines. Unlike bufio.ScanLines, carriage
+// returns are kept at the end of lines, since they are part of the value when
+// they precede a line break inside a quoted value.
+type lineScanner struct {
+	*bufio.Scanner
+	line int
+	// Lines given back by unread, scanned before the remaining input.
+	unreadLines []string
+	// The current line, when taken from unreadLines.
+	text       string
+	fromUnread bool
+}
+
+func newLineScanner(in io.Reader) *lineScanner {
+	ret := &lineScanner{Scanner: bufio.NewScanner(in)}
+	ret.Split(scanRawLines)
+	return ret
+}
+
+func scanRawLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func (self *lineScanner) Scan() bool {
+	if len(self.unreadLines) > 0 {
+		self.text, self.unreadLines = self.unreadLines[0], self.unreadLines[1:]
+		self.fromUnread = true
+		self.line += 1
+		return true
+	}
+	self.fromUnread = false
+	if !self.Scanner.Scan() {
+		return false
+	}
+	self.line += 1
+	return true
+}
+
+func (self *lineScanner) Text() string {
+	if self.fromUnread {
+		return self.text
+	}
+	return self.Scanner.Text()
+}
+
+// unread gives back the given lines, which must be the last scanned ones.
+func (self *lineScanner) unread(lines []string) {
+	self.unreadLines = append(lines[:len(lines):len(lines)], self.unreadLines...)
+	self.line -= len(lines)
+}
+
 func ReadFile(in io.Reader) (ret *File, err error) {
 	ret = &File{sections: make(map[string]*Section)}
-	scan := bufio.NewScanner(in)
+	scan := newLineScanner(in)
 	sectionTitle := ""
 
 	for true {
@@ -53,7 +183,7 @@ func ReadFile(in io.Reader) (ret *File, err error) {
 
 		var nextTitle string
 		var section *Section
-		section, nextTitle, err = newSection(scan)
+		section, nextTitle, err = newSection(scan, sectionTitle)
 		if err != nil {
 			return
 		}
@@ -65,46 +195,171 @@ func ReadFile(in io.Reader) (ret *File, err error) {
 }
 
 var (
-	spliterSemicolon = regexp.MustCompile("\\s*;\\s*")
-	spliterComma     = regexp.MustCompile("\\s*,\\s*")
+	spliterComma = regexp.MustCompile("\\s*,\\s*")
 )
 
-func splitScanned(scan *bufio.Scanner) []string {
-	return spliterSemicolon.Split(strings.TrimSpace(scan.Text()), -1)
+// scanRecord scans the next record. Records are lines of semicolon separated
+// values, except that values enclosed in double quotes may contain semicolons,
+// line breaks, and double quotes escaped by doubling them. The returned boolean
+// is false when there is no record left to scan. On WrongFormat error, the
+// returned raw string contains the malformed record.
+func scanRecord(scan *lineScanner) (record []string, raw string, ok bool, err error) {
+	if !scan.Scan() {
+		return nil, "", false, scan.Err()
+	}
+	raw = scan.Text()
+	for {
+		var complete bool
+		// The carriage return of a complete record ends the line.
+		trimmed := strings.TrimSuffix(raw, "\r")
+		if record, complete, err = splitRecord(trimmed); err != nil || complete {
+			return record, trimmed, true, err
+		}
+		if !scan.Scan() {
+			err = scan.Err()
+			if err == nil {
+				err = WrongFormat
+			}
+			return nil, raw, true, err
+		}
+		raw += "\n" + scan.Text()
+	}
 }
 
-func newSection(scan *bufio.Scanner) (section *Section, nextTitle string, err error) {
-	if !scan.Scan() {
-		err = scan.Err()
-		if err == nil {
-			err = WrongFormat
+// splitRecord splits a line into values. Unquoted values are trimmed. The
+// returned boolean is false when the line ends inside a quoted value.
+func splitRecord(line string) (record []string, complete bool, err error) {
+	pos, end := 0, len(line)
+	for {
+		start := pos
+		for pos < end && (line[pos] == ' ' || line[pos] == '\t') {
+			pos += 1
 		}
-		return
+
+		if pos < end && line[pos] == '"' {
+			var value strings.Builder
+			pos += 1
+			for {
+				quote := strings.IndexByte(line[pos:], '"')
+				if quote < 0 {
+					return nil, false, nil
+				}
+				value.WriteString(line[pos : pos+quote])
+				pos += quote + 1
+				if pos < end && line[pos] == '"' {
+					value.WriteByte('"')
+					pos += 1
+					continue
+				}
+				break
+			}
+			for pos < end && (line[pos] == ' ' || line[pos] == '\t') {
+				pos += 1
+			}
+			if pos < end && line[pos] != ';' {
+				return nil, true, WrongFormat
+			}
+			record = append(record, value.String())
+		} else {
+			sep := strings.IndexByte(line[start:], ';')
+			if sep < 0 {
+				pos = end
+			} else {
+				pos = start + sep
+			}
+			record = append(record, strings.TrimSpace(line[start:pos]))
+		}
+
+		if pos >= end {
+			return record, true, nil
+		}
+		pos += 1
 	}
-	section = &Section{Fields: splitScanned(scan)}
-	nbFields := len(section.Fields)
-	if nbFields == 1 {
-		err = WrongFormat
+}
+
+func newSection(scan *lineScanner, name string) (section *Section, nextTitle string, err error) {
+	reader, err := openSection(scan, name)
+	if err != nil {
 		return
 	}
-
-	for scan.Scan() {
-		line := splitScanned(scan)
-		lineLen := len(line)
-		if lineLen != nbFields {
-			if lineLen == 1 {
-				nextTitle = line[0]
-				return
-			}
-			err = WrongFormat
+	for {
+		line, ok := reader.next()
+		if !ok {
+			return reader.section, reader.nextTitle, reader.err
 		}
-		section.Lines = append(section.Lines, line)
+		reader.section.Lines = append(reader.section.Lines, line)
+		reader.section.lineNums = append(reader.section.lineNums, reader.line)
 	}
+}
+
+// sectionReader reads the records of a section one at a time.
+type sectionReader struct {
+	scan *lineScanner
+	name string
+	// The section, with its fields only.
+	section *Section
+	// The 1-based number of the line where the last returned record starts.
+	line int
+	// The title of the following section, once the end of this one is reached.
+	nextTitle string
+	// Whether the end of the section, or an error, has been reached.
+	ended bool
+	err   error
+}
 
-	err = scan.Err()
+// openSection reads the fields of a section.
+func openSection(scan *lineScanner, name string) (ret *sectionReader, err error) {
+	ret = &sectionReader{scan: scan, name: name}
+	lineNum := scan.line + 1
+	fields, raw, ok, err := scanRecord(scan)
+	if !ok || err != nil {
+		return nil, ret.fail(err, lineNum, 0, 0, raw)
+	}
+	if len(fields) == 1 {
+		return nil, ret.fail(err, lineNum, 2, 1, raw)
+	}
+	ret.section = NewSection(fields, nil)
 	return
 }
 
+// next returns the next record of the section. The returned boolean is false
+// at the end of the section or on error.
+func (self *sectionReader) next() (line []string, ok bool) {
+	if self.ended {
+		return nil, false
+	}
+
+	lineNum := self.scan.line + 1
+	line, raw, ok, err := scanRecord(self.scan)
+	if !ok || err != nil {
+		self.ended = true
+		if err != nil {
+			self.err = self.fail(err, lineNum, 0, 0, raw)
+		}
+		return nil, false
+	}
+	lineLen, nbFields := len(line), len(self.section.Fields)
+	if lineLen != nbFields {
+		self.ended = true
+		if lineLen == 1 {
+			self.nextTitle = line[0]
+		} else {
+			self.err = self.fail(nil, lineNum, nbFields, lineLen, raw)
+		}
+		return nil, false
+	}
+	self.line = lineNum
+	return line, true
+}
+
+// fail wraps format errors into a ParseError. I/O errors are returned as is.
+func (self *sectionReader) fail(err error, lineNum, expected, got int, raw string) error {
+	if err == nil || err == WrongFormat {
+		return ParseError{Section: self.name, Line: lineNum, Expected: expected, Got: got, Raw: raw}
+	}
+	return err
+}
+
 func (self *File) Get(sectionName string) (section *Section, ok bool) {
 	section, ok = self.sections[sectionName]
 	return
@@ -115,29 +370,14 @@ func (self *File) Get(sectionName string) (section *Section, ok bool) {
 // Negative values indicate that field names have not been found.
 // The returned boolean is true only if all field names have been found.
 func (self *Section) FieldIndexes(fields []string) (indexes []int, ok bool) {
-	count := len(fields)
-	indexes = make([]int, count)
-	posMap := make(map[string]int, count)
+	indexes = make([]int, len(fields))
+	ok = true
 	for i, field := range fields {
-		indexes[i] = -1
-		posMap[field] = i
-	}
-
-	found := 0
-	for i, field := range self.Fields {
-		pos, tmpOk := posMap[field]
-		if tmpOk {
-			if indexes[pos] < 0 {
-				found += 1
-			}
-			indexes[pos] = i
-			if found == count {
-				break
-			}
+		var found bool
+		if indexes[i], found = self.fieldIndex(field); !found {
+			ok = false
 		}
 	}
-
-	ok = found == count
 	return
 }
 
@@ -146,10 +386,10 @@ func (self *Section) Cell(line int, field string) (string, bool) {
 		return "", false
 	}
 
-	index, ok := self.FieldIndexes([]string{field})
+	index, ok := self.fieldIndex(field)
 	if !ok {
 		return "", false
 	}
 
-	return self.Lines[line][index[0]], true
+	return self.Lines[line][index], true
 }
@@ -43,6 +43,21 @@ func (self ftError) testFile(t *testing.T, _ *File, err error) {
 	}
 }
 
+type ftParseError struct {
+	err ParseError
+}
+
+func (self ftParseError) testFile(t *testing.T, _ *File, err error) {
+	var got ParseError
+	if !errors.As(err, &got) {
+		t.Errorf("Got error %v. Expect ParseError.", err)
+		return
+	}
+	if !reflect.DeepEqual(got, self.err) {
+		t.Errorf("Wrong ParseError. Got %#v. Expect %#v.", got, self.err)
+	}
+}
+
 type ftHasSection struct {
 	name  string
 	tests []sectionTester
@@ -151,6 +166,71 @@ func TestReadFile(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Quoted values",
+			data: "foo\nkey;value\n\"a;b\" ; \"say \"\"hi\"\"\"\n \" x \";\"\"\n",
+			tests: []fileTester{&ftHasSection{
+				name: "foo",
+				tests: []sectionTester{
+					stHasFields{[]string{"key", "value"}},
+					stHasValues{[][]string{{"a;b", `say "hi"`}, {" x ", ""}}},
+				},
+			}},
+		},
+		{
+			name: "Multi-line value",
+			data: "foo\nkey;value\nbar;\"first\nsecond\"\nbaz;flu\n",
+			tests: []fileTester{&ftHasSection{
+				name: "foo",
+				tests: []sectionTester{
+					stHasFields{[]string{"key", "value"}},
+					stHasValues{[][]string{{"bar", "first\nsecond"}, {"baz", "flu"}}},
+				},
+			}},
+		},
+		{
+			name: "Error position",
+			data: "META\nkey;value\nbudget;1\nVOTES\nvoter_id;vote\n0;1\n1;1;2\n",
+			tests: []fileTester{ftParseError{ParseError{
+				Section:  "VOTES",
+				Line:     7,
+				Expected: 2,
+				Got:      3,
+				Raw:      "1;1;2",
+			}}},
+		},
+		{
+			name: "Multi-line error position",
+			data: "foo\nkey;value\nbar;\"baz\nflu\";\n",
+			tests: []fileTester{ftParseError{ParseError{
+				Section:  "foo",
+				Line:     3,
+				Expected: 2,
+				Got:      3,
+				Raw:      "bar;\"baz\nflu\";",
+			}}},
+		},
+		{
+			name: "Carriage returns",
+			data: "foo\r\nkey;value\r\nbar;\"x\r\ny\"\r\nbaz;\"flu\"\r\n",
+			tests: []fileTester{&ftHasSection{
+				name: "foo",
+				tests: []sectionTester{
+					stHasFields{[]string{"key", "value"}},
+					stHasValues{[][]string{{"bar", "x\r\ny"}, {"baz", "flu"}}},
+				},
+			}},
+		},
+		{
+			name:  "Unclosed quote",
+			data:  "foo\nkey;value\nbar;\"baz\n",
+			tests: []fileTester{ftError{WrongFormat}},
+		},
+		{
+			name:  "Text after quote",
+			data:  "foo\nkey;value\nbar;\"baz\"flu\n",
+			tests: []fileTester{ftError{WrongFormat}},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -259,3 +339,32 @@ func TestSection_Cell(t *testing.T) {
 		})
 	}
 }
+
+func TestNewSection_Renamed(t *testing.T) {
+	section := NewSection([]string{"foo", "bar"}, [][]string{{"a", "b"}})
+	section.Fields[1] = "baz"
+	if got, ok := section.Cell(0, "baz"); !ok || got != "b" {
+		t.Errorf("Wrong renamed field. Got %s, %t. Expect b, true.", got, ok)
+	}
+	if _, ok := section.Cell(0, "bar"); ok {
+		t.Errorf("Old field name still found.")
+	}
+}
+
+func TestSectionReader_Ended(t *testing.T) {
+	scan := newLineScanner(strings.NewReader("voter_id;vote\n0;a\n\n1;b\n"))
+	reader, err := openSection(scan, "VOTES")
+	mustt(t, err)
+
+	if _, ok := reader.next(); !ok {
+		t.Fatalf("First record not read.")
+	}
+	for i := 0; i < 2; i++ {
+		if line, ok := reader.next(); ok {
+			t.Errorf("Record %v read after the end of the section.", line)
+		}
+	}
+	if scan.line != 3 {
+		t.Errorf("Wrong line after the section. Got %d. Expect 3.", scan.line)
+	}
+}
@@ -0,0 +1,122 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"math"
+)
+
+// lpTolerance is the absolute tolerance of the simplex method.
+const lpTolerance = 1e-9
+
+// lpFeasible searches for x >= 0 such that a x = b, by the first phase of the
+// simplex method with Bland's rule. All values of b must be non-negative. It
+// returns nil if there is no solution.
+func lpFeasible(a [][]float64, b []float64) []float64 {
+	rows := len(a)
+	if rows == 0 {
+		return []float64{}
+	}
+	cols := len(a[0])
+
+	// The tableau has one artificial variable per row, and the right-hand side
+	// in the last column. The last row holds the reduced costs of the sum of
+	// the artificial variables.
+	width := cols + rows + 1
+	tableau := make([][]float64, rows+1)
+	objective := make([]float64, width)
+	basis := make([]int, rows)
+	for i := range a {
+		row := make([]float64, width)
+		copy(row, a[i])
+		row[cols+i] = 1
+		row[width-1] = b[i]
+		tableau[i] = row
+		basis[i] = cols + i
+		for j := 0; j < cols; j++ {
+			objective[j] -= a[i][j]
+		}
+		objective[width-1] -= b[i]
+	}
+	tableau[rows] = objective
+
+	for {
+		enter := -1
+		for j := 0; j < cols+rows; j++ {
+			if objective[j] < -lpTolerance {
+				enter = j
+				break
+			}
+		}
+		if enter < 0 {
+			break
+		}
+
+		leave := -1
+		var ratio float64
+		for i := 0; i < rows; i++ {
+			if coef := tableau[i][enter]; coef > lpTolerance {
+				r := tableau[i][width-1] / coef
+				if leave < 0 || r < ratio-lpTolerance ||
+					(math.Abs(r-ratio) <= lpTolerance && basis[i] < basis[leave]) {
+					leave, ratio = i, r
+				}
+			}
+		}
+		if leave < 0 {
+			// Cannot happen, since the objective is bounded below by zero.
+			return nil
+		}
+		lpPivot(tableau, leave, enter)
+		basis[leave] = enter
+	}
+
+	if -objective[width-1] > lpTolerance*math.Max(1, maxAbs(b)) {
+		return nil
+	}
+	ret := make([]float64, cols)
+	for i, variable := range basis {
+		if variable < cols {
+			ret[variable] = math.Max(0, tableau[i][width-1])
+		}
+	}
+	return ret
+}
+
+func lpPivot(tableau [][]float64, row, col int) {
+	pivot := tableau[row]
+	factor := pivot[col]
+	for j := range pivot {
+		pivot[j] /= factor
+	}
+	for i, other := range tableau {
+		if i == row || other[col] == 0 {
+			continue
+		}
+		coef := other[col]
+		for j := range other {
+			other[j] -= coef * pivot[j]
+		}
+	}
+}
+
+func maxAbs(values []float64) (ret float64) {
+	for _, value := range values {
+		ret = math.Max(ret, math.Abs(value))
+	}
+	return
+}
@@ -0,0 +1,326 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"math"
+	"sort"
+)
+
+// Utility functions.
+const (
+	// The utility of a voter for a project is the value given by the vote: 1
+	// for approved projects, the points for cumulative and scoring votes, and
+	// the score given by the scoring function for ordinal votes.
+	UtilityCardinal = iota
+	// The utility of a voter for an approved project is its cost. Only
+	// available for approval votes.
+	UtilityCost
+)
+
+// Completion methods, used when the Method of Equal Shares does not exhaust
+// the budget.
+const (
+	CompletionNone = iota
+	// Add the remaining projects by decreasing total utility, as long as they
+	// fit in the budget.
+	CompletionGreedy
+	// Increase the budget of each voter by one unit as long as the outcome fits
+	// in the actual budget.
+	CompletionAddOne
+	// Add the remaining projects by decreasing ratio of total utility over
+	// cost, as long as they fit in the budget.
+	CompletionUtilitarian
+)
+
+type MESOptions struct {
+	Utility    int
+	Completion int
+	// Use exact rational arithmetic instead of floats.
+	Exact bool
+	// How ties are broken. Nil means file order.
+	Ties TieBreaker
+}
+
+// EqualShares computes the outcome of the Method of Equal Shares. The budget
+// is split equally among the voters, and projects are bought one at a time,
+// always choosing the project minimizing the price per unit of utility paid by
+// its supporters. Voters with a non-positive utility for a project do not
+// support it. Ties are broken by the tie breaker of the options. The score of each
+// step of the trace is that price, or the completion criterion for steps added
+// by CompletionGreedy and CompletionUtilitarian.
+func EqualShares(pb PB, opts MESOptions) (ret Outcome, err error) {
+	instance, err := newMesInstance(pb, opts)
+	if err != nil {
+		return
+	}
+
+	budget := pb.Budget()
+	var selected []int
+	var trace []Step
+
+	if opts.Completion == CompletionAddOne {
+		selected, trace = instance.addOne(budget)
+	} else {
+		selected, trace = instance.run(budget)
+	}
+
+	switch opts.Completion {
+	case CompletionGreedy:
+		selected, trace = instance.complete(budget, selected, trace, instance.totalUtility)
+	case CompletionUtilitarian:
+		ratios := make([]float64, len(instance.costs))
+		for i, utility := range instance.totalUtility {
+			if instance.costs[i] == 0 {
+				ratios[i] = math.Inf(1)
+			} else {
+				ratios[i] = utility / float64(instance.costs[i])
+			}
+		}
+		selected, trace = instance.complete(budget, selected, trace, ratios)
+	}
+
+	ret = newOutcome(pb, selected)
+	ret.Trace = trace
+	return
+}
+
+type mesSupporter struct {
+	voter   int
+	utility number
+}
+
+type mesInstance struct {
+	pb           PB
+	ties         TieBreaker
+	nums         numbers
+	ids          []string
+	costs        []int
+	numVoters    int
+	supporters   [][]mesSupporter
+	totalUtility []float64
+}
+
+func newMesInstance(pb PB, opts MESOptions) (ret *mesInstance, err error) {
+	if opts.Utility == UtilityCost && pb.VoteType() != VoteTypeApproval {
+		return nil, UnsupportedVoteType
+	}
+	ballots, err := profile(pb)
+	if err != nil {
+		return
+	}
+
+	ret = &mesInstance{
+		pb:           pb,
+		ties:         opts.Ties,
+		nums:         numbers{exact: opts.Exact},
+		ids:          projectIds(pb),
+		costs:        projectCosts(pb),
+		numVoters:    len(ballots),
+		supporters:   make([][]mesSupporter, pb.CountProjects()),
+		totalUtility: make([]float64, pb.CountProjects()),
+	}
+	for voter, b := range ballots {
+		for i, project := range b.projects {
+			utility := b.utilities[i]
+			if utility <= 0 {
+				continue
+			}
+			if opts.Utility == UtilityCost {
+				utility = float64(ret.costs[project])
+			}
+			ret.supporters[project] = append(ret.supporters[project],
+				mesSupporter{voter: voter, utility: ret.nums.float(utility)})
+			ret.totalUtility[project] += utility
+		}
+	}
+	return
+}
+
+// run executes the method with the given total budget, without completion.
+func (self *mesInstance) run(budget int) (selected []int, trace []Step) {
+	if self.numVoters == 0 {
+		return
+	}
+	budgets := make([]number, self.numVoters)
+	share := self.nums.frac(budget, self.numVoters)
+	for i := range budgets {
+		budgets[i] = share
+	}
+
+	candidates := make([]int, len(self.costs))
+	for i := range candidates {
+		candidates[i] = i
+	}
+	remaining := budget
+
+	for {
+		var (
+			tied    []int
+			bestRho number
+		)
+		feasible := candidates[:0]
+		for _, project := range candidates {
+			rho, ok := self.price(project, budgets)
+			if !ok {
+				// Budgets only decrease, hence the project will never be affordable.
+				continue
+			}
+			feasible = append(feasible, project)
+			switch {
+			case len(tied) == 0 || rho.Cmp(bestRho) < 0:
+				tied, bestRho = append(tied[:0], project), rho
+			case rho.Cmp(bestRho) == 0:
+				tied = append(tied, project)
+			}
+		}
+		if len(tied) == 0 {
+			return
+		}
+		best := breakTie(self.pb, self.ties, tied)
+
+		for _, s := range self.supporters[best] {
+			payment := minNumber(budgets[s.voter], bestRho.Mul(s.utility))
+			budgets[s.voter] = budgets[s.voter].Sub(payment)
+		}
+		remaining -= self.costs[best]
+		selected = append(selected, best)
+		trace = append(trace, Step{
+			Project:   self.ids[best],
+			Score:     bestRho.Float64(),
+			Cost:      self.costs[best],
+			Selected:  true,
+			Remaining: remaining,
+		})
+
+		candidates = feasible[:0]
+		for _, project := range feasible {
+			if project != best {
+				candidates = append(candidates, project)
+			}
+		}
+	}
+}
+
+// price computes the minimal price per unit of utility for which the
+// supporters of the project can buy it. The returned boolean is false if the
+// supporters cannot afford the project.
+func (self *mesInstance) price(project int, budgets []number) (rho number, ok bool) {
+	type entry struct {
+		budget, utility, ratio number
+	}
+	supporters := self.supporters[project]
+	entries := make([]entry, len(supporters))
+	total := self.nums.int(0)
+	utility := self.nums.int(0)
+	for i, s := range supporters {
+		b := budgets[s.voter]
+		entries[i] = entry{budget: b, utility: s.utility, ratio: b.Quo(s.utility)}
+		total = total.Add(b)
+		utility = utility.Add(s.utility)
+	}
+
+	cost := self.nums.int(self.costs[project])
+	if total.Cmp(cost) < 0 {
+		return nil, false
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ratio.Cmp(entries[j].ratio) < 0
+	})
+	for _, e := range entries {
+		if e.ratio.Mul(utility).Cmp(cost) >= 0 {
+			return cost.Quo(utility), true
+		}
+		cost = cost.Sub(e.budget)
+		utility = utility.Sub(e.utility)
+	}
+	return nil, false
+}
+
+// addOne runs the method with increasing budgets, as long as the outcome fits
+// in the given budget.
+func (self *mesInstance) addOne(budget int) (selected []int, trace []Step) {
+	if self.numVoters == 0 {
+		// The virtual budget would never increase.
+		return
+	}
+	totalCost := 0
+	for _, cost := range self.costs {
+		totalCost += cost
+	}
+
+	selected, trace = self.run(budget)
+	for virtual := budget + self.numVoters; virtual <= self.numVoters*totalCost; virtual += self.numVoters {
+		if self.exhaustive(selected, budget) {
+			break
+		}
+		nextSelected, nextTrace := self.run(virtual)
+		if self.cost(nextSelected) > budget {
+			break
+		}
+		selected, trace = nextSelected, nextTrace
+	}
+
+	// Steps computed with a virtual budget record the remaining virtual budget.
+	remaining := budget
+	for i := range trace {
+		remaining -= trace[i].Cost
+		trace[i].Remaining = remaining
+	}
+	return
+}
+
+func (self *mesInstance) cost(selected []int) (ret int) {
+	for _, project := range selected {
+		ret += self.costs[project]
+	}
+	return
+}
+
+// exhaustive returns whether no other project fits in the remaining budget.
+func (self *mesInstance) exhaustive(selected []int, budget int) bool {
+	remaining := budget - self.cost(selected)
+	isSelected := make([]bool, len(self.costs))
+	for _, project := range selected {
+		isSelected[project] = true
+	}
+	for project, cost := range self.costs {
+		if !isSelected[project] && cost <= remaining {
+			return false
+		}
+	}
+	return true
+}
+
+// complete examines the projects not yet selected by decreasing score, and
+// selects those that fit in the remaining budget.
+func (self *mesInstance) complete(budget int, selected []int, trace []Step, scores []float64) ([]int, []Step) {
+	isSelected := make([]bool, len(self.costs))
+	for _, project := range selected {
+		isSelected[project] = true
+	}
+	projects := make([]int, 0, len(self.costs)-len(selected))
+	for project := range self.costs {
+		if !isSelected[project] {
+			projects = append(projects, project)
+		}
+	}
+
+	added, steps := greedyPass(self.pb, self.ties, projects, scores, budget-self.cost(selected), false)
+	return append(selected, added...), append(trace, steps...)
+}
@@ -0,0 +1,114 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEqualShares(t *testing.T) {
+	approval := makeRuleData("approval", 100, []string{"a:60", "b:40", "c:30"},
+		[][]string{{"a"}, {"a"}, {"a"}, {"b,c"}})
+
+	tests := []struct {
+		name     string
+		data     string
+		opts     MESOptions
+		selected []string
+		err      error
+	}{
+		{
+			name:     "No completion",
+			data:     approval,
+			selected: []string{"a"},
+		},
+		{
+			name:     "Greedy completion",
+			data:     approval,
+			opts:     MESOptions{Completion: CompletionGreedy},
+			selected: []string{"a", "b"},
+		},
+		{
+			name:     "Utilitarian completion",
+			data:     approval,
+			opts:     MESOptions{Completion: CompletionUtilitarian},
+			selected: []string{"a", "c"},
+		},
+		{
+			name:     "Add one",
+			data:     approval,
+			opts:     MESOptions{Completion: CompletionAddOne},
+			selected: []string{"a", "c"},
+		},
+		{
+			name:     "Exact add one",
+			data:     approval,
+			opts:     MESOptions{Completion: CompletionAddOne, Exact: true},
+			selected: []string{"a", "c"},
+		},
+		{
+			name:     "Add one without votes",
+			data:     makeRuleData("approval", 100, []string{"a:60", "b:40"}, nil),
+			opts:     MESOptions{Completion: CompletionAddOne},
+			selected: []string{},
+		},
+		{
+			name: "Cost utility",
+			data: makeRuleData("approval", 90, []string{"a:30", "b:60", "c:30"},
+				[][]string{{"a,b"}, {"a,b"}, {"b,c"}}),
+			opts:     MESOptions{Utility: UtilityCost},
+			selected: []string{"b"},
+		},
+		{
+			name: "Cardinal utility",
+			data: makeRuleData("approval", 90, []string{"a:30", "b:60", "c:30"},
+				[][]string{{"a,b"}, {"a,b"}, {"b,c"}}),
+			selected: []string{"a", "b"},
+		},
+		{
+			name: "Cumulative",
+			data: makeRuleData("cumulative", 20, []string{"a:10", "b:10", "c:10"},
+				[][]string{{"a,b", "1,9"}, {"a,c", "5,5"}}),
+			opts:     MESOptions{Exact: true},
+			selected: []string{"b", "a"},
+		},
+		{
+			name: "Cost utility on cumulative",
+			data: makeRuleData("cumulative", 20, []string{"a:10", "b:10"},
+				[][]string{{"a,b", "1,9"}}),
+			opts: MESOptions{Utility: UtilityCost},
+			err:  UnsupportedVoteType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, err := EqualShares(mustOpen(t, tt.data), tt.opts)
+			if tt.err != nil {
+				if err != tt.err {
+					t.Errorf("Got error %v. Expect error %v.", err, tt.err)
+				}
+				return
+			}
+			mustt(t, err)
+
+			if !reflect.DeepEqual(outcome.Selected, tt.selected) {
+				t.Errorf("Wrong selection. Got %v. Expect %v.", outcome.Selected, tt.selected)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"math"
+	"math/big"
+)
+
+// number is an immutable value used by rules needing divisions. It is either a
+// float64, compared with a relative tolerance, or an exact rational.
+type number interface {
+	Add(other number) number
+	Sub(other number) number
+	Mul(other number) number
+	Quo(other number) number
+	// Cmp returns -1, 0 or +1 depending on whether the receiver is less than,
+	// equal to or greater than other.
+	Cmp(other number) int
+	Float64() float64
+}
+
+// numbers creates numbers of one kind.
+type numbers struct {
+	exact bool
+}
+
+func (self numbers) int(value int) number {
+	if self.exact {
+		return ratNumber{new(big.Rat).SetInt64(int64(value))}
+	}
+	return floatNumber(value)
+}
+
+// float converts a value. In exact mode, the conversion is exact for integers
+// and dyadic fractions only.
+func (self numbers) float(value float64) number {
+	if self.exact {
+		return ratNumber{new(big.Rat).SetFloat64(value)}
+	}
+	return floatNumber(value)
+}
+
+func (self numbers) frac(num, denom int) number {
+	if self.exact {
+		return ratNumber{big.NewRat(int64(num), int64(denom))}
+	}
+	return floatNumber(float64(num) / float64(denom))
+}
+
+// floatTolerance is the relative tolerance of floatNumber comparisons.
+const floatTolerance = 1e-9
+
+type floatNumber float64
+
+func (self floatNumber) Add(other number) number {
+	return self + other.(floatNumber)
+}
+
+func (self floatNumber) Sub(other number) number {
+	return self - other.(floatNumber)
+}
+
+func (self floatNumber) Mul(other number) number {
+	return self * other.(floatNumber)
+}
+
+func (self floatNumber) Quo(other number) number {
+	return self / other.(floatNumber)
+}
+
+func (self floatNumber) Cmp(other number) int {
+	a, b := float64(self), float64(other.(floatNumber))
+	if math.Abs(a-b) <= floatTolerance*math.Max(1, math.Max(math.Abs(a), math.Abs(b))) {
+		return 0
+	}
+	if a < b {
+		return -1
+	}
+	return 1
+}
+
+func (self floatNumber) Float64() float64 {
+	return float64(self)
+}
+
+type ratNumber struct {
+	rat *big.Rat
+}
+
+func (self ratNumber) Add(other number) number {
+	return ratNumber{new(big.Rat).Add(self.rat, other.(ratNumber).rat)}
+}
+
+func (self ratNumber) Sub(other number) number {
+	return ratNumber{new(big.Rat).Sub(self.rat, other.(ratNumber).rat)}
+}
+
+func (self ratNumber) Mul(other number) number {
+	return ratNumber{new(big.Rat).Mul(self.rat, other.(ratNumber).rat)}
+}
+
+func (self ratNumber) Quo(other number) number {
+	return ratNumber{new(big.Rat).Quo(self.rat, other.(ratNumber).rat)}
+}
+
+func (self ratNumber) Cmp(other number) int {
+	return self.rat.Cmp(other.(ratNumber).rat)
+}
+
+func (self ratNumber) Float64() float64 {
+	ret, _ := self.rat.Float64()
+	return ret
+}
+
+func minNumber(a, b number) number {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
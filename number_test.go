@@ -0,0 +1,50 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"testing"
+)
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		name  string
+		exact bool
+	}{
+		{name: "Float", exact: false},
+		{name: "Exact", exact: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nums := numbers{exact: tt.exact}
+			third := nums.frac(1, 3)
+			sum := third.Add(third).Add(third)
+			if got := sum.Cmp(nums.int(1)); got != 0 {
+				t.Errorf("Wrong comparison. Got %d. Expect %d.", got, 0)
+			}
+			if got := nums.int(1).Sub(third).Cmp(third.Mul(nums.int(2))); got != 0 {
+				t.Errorf("Wrong comparison. Got %d. Expect %d.", got, 0)
+			}
+			if got := third.Cmp(nums.float(0.3334)); got != -1 {
+				t.Errorf("Wrong comparison. Got %d. Expect %d.", got, -1)
+			}
+			if got := nums.int(2).Quo(nums.int(3)).Float64(); got < 0.666 || got > 0.667 {
+				t.Errorf("Wrong Float64. Got %f. Expect %f.", got, 2./3.)
+			}
+		})
+	}
+}
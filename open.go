@@ -0,0 +1,77 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"io"
+	"os"
+)
+
+// Open reads a pabulib file and returns the PB implementation corresponding to
+// its vote_type meta key.
+func Open(in io.Reader) (PB, error) {
+	file, err := ReadFile(in)
+	if err != nil {
+		return nil, err
+	}
+	return NewPB(file)
+}
+
+// OpenPath is like Open but reads the file at the given path.
+func OpenPath(path string) (PB, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return Open(in)
+}
+
+// NewPB returns the PB implementation corresponding to the vote_type meta key
+// of the given file. An UnknownVoteType error is returned if that key does not
+// name one of the vote types defined by the pabulib format.
+func NewPB(file *File) (PB, error) {
+	return newPB(file, (*pbBase).checkPoints)
+}
+
+// newPB implements NewPB, with the given function checking the points of
+// cumulative and scoring votes.
+func newPB(file *File, checkPoints func(*pbBase) error) (PB, error) {
+	base, err := newPbBase(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch base.VoteType() {
+	case VoteTypeApproval:
+		return ApprovalPB{pbBase: base}, nil
+	case VoteTypeOrdinal:
+		return OrdinalPB{pbBase: base}, nil
+	case VoteTypeCumulative:
+		if err = checkPoints(base); err != nil {
+			return nil, err
+		}
+		return CumulativePB{pbBase: base}, nil
+	case VoteTypeScoring:
+		if err = checkPoints(base); err != nil {
+			return nil, err
+		}
+		return ScoringPB{pbBase: base}, nil
+	default:
+		return nil, UnknownVoteType{base.mustMeta("vote_type")}
+	}
+}
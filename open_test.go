@@ -0,0 +1,116 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func makePBData(voteType string) string {
+	return "META\n" +
+		"key;value\n" +
+		"num_projects;2\n" +
+		"num_votes;2\n" +
+		"budget;1000\n" +
+		"vote_type;" + voteType + "\n" +
+		"rule;greedy\n" +
+		"PROJECTS\n" +
+		"project_id;cost\n" +
+		"1;600\n" +
+		"2;400\n" +
+		"VOTES\n" +
+		"voter_id;vote;points\n" +
+		"0;1,2;3,2\n" +
+		"1;2;1\n"
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		voteType int
+		err      error
+	}{
+		{
+			name:     "Ordinal",
+			data:     makePBData("ordinal"),
+			voteType: VoteTypeOrdinal,
+		},
+		{
+			name:     "Approval",
+			data:     makePBData("approval"),
+			voteType: VoteTypeApproval,
+		},
+		{
+			name:     "Cumulative",
+			data:     makePBData("cumulative"),
+			voteType: VoteTypeCumulative,
+		},
+		{
+			name: "Cumulative without points",
+			data: strings.Replace(makePBData("cumulative"), ";points", ";score", 1),
+			err:  MissingRequiredField{"points"},
+		},
+		{
+			name:     "Scoring",
+			data:     makePBData("scoring"),
+			voteType: VoteTypeScoring,
+		},
+		{
+			name: "Unknown",
+			data: makePBData("plurality"),
+			err:  UnknownVoteType{"plurality"},
+		},
+		{
+			name: "Missing section",
+			data: "META\nkey;value\nbudget;1000\n",
+			err:  MissingRequiredSection{"PROJECTS"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pb, err := Open(strings.NewReader(tt.data))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("Got error %v. Expect error %v.", err, tt.err)
+				}
+				return
+			}
+			mustt(t, err)
+
+			if got := pb.VoteType(); got != tt.voteType {
+				t.Errorf("Wrong VoteType. Got %d. Expect %d.", got, tt.voteType)
+			}
+			var ok bool
+			switch tt.voteType {
+			case VoteTypeApproval:
+				_, ok = pb.(ApprovalPB)
+			case VoteTypeOrdinal:
+				_, ok = pb.(OrdinalPB)
+			case VoteTypeCumulative:
+				_, ok = pb.(CumulativePB)
+			case VoteTypeScoring:
+				_, ok = pb.(ScoringPB)
+			}
+			if !ok {
+				t.Errorf("Wrong PB type %T.", pb)
+			}
+		})
+	}
+}
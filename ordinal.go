@@ -16,7 +16,10 @@
 
 package pabulib
 
-import ()
+import (
+	"strconv"
+	"strings"
+)
 
 type OrdinalVote struct {
 	// An ordered list of project identifiers.
@@ -29,15 +32,10 @@ type OrdinalPB struct {
 	*pbBase
 }
 
-func newOrdinalVote(section *Section, line int) (ret OrdinalVote) {
-	voteStr, ok := section.Cell(line, "vote")
-	if !ok {
-		panic(MissingRequiredField{"vote"})
-	}
-	return OrdinalVote{
-		voteBase: newVoteBase(section, line),
-		Vote:     spliterComma.Split(voteStr, -1),
-	}
+func newOrdinalVote(base voteBase) (ret OrdinalVote) {
+	ret.voteBase = base
+	ret.Vote = ret.mustList("vote")
+	return
 }
 
 func newOrdinalPB(file *File) (ret OrdinalPB, err error) {
@@ -47,7 +45,7 @@ func newOrdinalPB(file *File) (ret OrdinalPB, err error) {
 }
 
 func (self OrdinalPB) Vote(index int) Vote {
-	return newOrdinalVote(self.votesSection, index)
+	return newOrdinalVote(newVoteBase(self.votesSection, index))
 }
 
 func (self OrdinalPB) MinLength() int {
@@ -55,9 +53,86 @@ func (self OrdinalPB) MinLength() int {
 }
 
 func (self OrdinalPB) MaxLength() int {
-	return self.defaultMetaInt("max_length", self.NumProjects())
+	return self.defaultMetaInt("max_length", self.CountProjects())
 }
 
 func (self OrdinalPB) ScoringFn() string {
 	return self.defaultMeta("scoring_fn", "Borda")
 }
+
+// ScoringFunction returns the parsed scoring function of the PB.
+func (self OrdinalPB) ScoringFunction() (ScoringFunction, error) {
+	return ParseScoringFn(self.ScoringFn(), self.MaxLength())
+}
+
+// Scores returns the score of each project ranked by the vote, according to
+// the scoring function of the PB. Only the first MaxLength projects of the
+// ranking are scored. An InvalidField error is returned if the vote ranks less
+// than MinLength projects.
+func (self OrdinalPB) Scores(vote OrdinalVote) (map[string]float64, error) {
+	fn, err := self.ScoringFunction()
+	if err != nil {
+		return nil, err
+	}
+	return vote.scores(fn, self.MinLength(), self.MaxLength())
+}
+
+func (self OrdinalVote) scores(fn ScoringFunction, minLength, maxLength int) (ret map[string]float64, err error) {
+	if len(self.Vote) < minLength {
+		return nil, InvalidField{Section: "VOTES", Line: self.line, Field: "vote", Value: self.mustField("vote")}
+	}
+	ret = make(map[string]float64, len(self.Vote))
+	for pos, project := range self.Vote {
+		if pos >= maxLength {
+			break
+		}
+		if _, dup := ret[project]; !dup {
+			ret[project] = fn(pos)
+		}
+	}
+	return
+}
+
+// ScoringFunction gives the score of the project at the given position of a
+// ranking, starting at 0.
+type ScoringFunction func(position int) float64
+
+// ParseScoringFn parses a scoring function, as given by the scoring_fn meta
+// key. Recognized expressions are Borda, where the project at position p of a
+// ranking of maximal length maxLength scores maxLength - p, Dowdall, where it
+// scores 1 / (p + 1), and comma separated lists of numbers giving the score of
+// each position, positions beyond the list scoring 0. Names are case
+// insensitive. An InvalidMeta error is returned for other expressions.
+func ParseScoringFn(expr string, maxLength int) (ScoringFunction, error) {
+	switch strings.ToLower(strings.TrimSpace(expr)) {
+	case "borda":
+		return func(position int) float64 {
+			if position >= maxLength {
+				return 0
+			}
+			return float64(maxLength - position)
+		}, nil
+	case "dowdall":
+		return func(position int) float64 {
+			return 1 / float64(position+1)
+		}, nil
+	}
+
+	list := splitList(strings.TrimSpace(expr))
+	if len(list) == 0 {
+		return nil, InvalidMeta{Meta: "scoring_fn", Value: expr}
+	}
+	vector := make([]float64, len(list))
+	for i, str := range list {
+		var err error
+		if vector[i], err = strconv.ParseFloat(str, 64); err != nil {
+			return nil, InvalidMeta{Meta: "scoring_fn", Value: expr}
+		}
+	}
+	return func(position int) float64 {
+		if position >= len(vector) {
+			return 0
+		}
+		return vector[position]
+	}, nil
+}
@@ -18,6 +18,7 @@ package pabulib
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -171,3 +172,97 @@ func TestOrdinalPB(t *testing.T) {
 		})
 	}
 }
+
+func TestParseScoringFn(t *testing.T) {
+	tests := []struct {
+		expr   string
+		scores []float64
+		err    bool
+	}{
+		{expr: "Borda", scores: []float64{3, 2, 1, 0}},
+		{expr: " borda", scores: []float64{3, 2, 1, 0}},
+		{expr: "Dowdall", scores: []float64{1, 0.5, 1. / 3, 0.25}},
+		{expr: "5, 2,1.5", scores: []float64{5, 2, 1.5, 0}},
+		{expr: "none", err: true},
+		{expr: "3,,1", err: true},
+		{expr: "", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			fn, err := ParseScoringFn(tt.expr, 3)
+			if tt.err {
+				if err != (InvalidMeta{Meta: "scoring_fn", Value: tt.expr}) {
+					t.Errorf("Got error %v. Expect InvalidMeta.", err)
+				}
+				return
+			}
+			mustt(t, err)
+			for pos, expect := range tt.scores {
+				if got := fn(pos); got != expect {
+					t.Errorf("Wrong score at position %d. Got %f. Expect %f.", pos, got, expect)
+				}
+			}
+		})
+	}
+}
+
+func TestOrdinalPB_Scores(t *testing.T) {
+	tests := []struct {
+		name   string
+		meta   string
+		scores []map[string]float64
+		err    error
+		// The error expected from profile, if different from err.
+		profileErr error
+	}{
+		{
+			name: "Default",
+			scores: []map[string]float64{
+				{"a": 3, "b": 2, "c": 1},
+				{"c": 3},
+			},
+		},
+		{
+			name: "Truncated",
+			meta: "max_length;2\nscoring_fn;2,1\n",
+			scores: []map[string]float64{
+				{"a": 2, "b": 1},
+				{"c": 2},
+			},
+		},
+		{
+			name: "Too short",
+			meta: "min_length;2\n",
+			err:  InvalidField{Section: "VOTES", Line: 1, Field: "vote", Value: "c"},
+			// Only reported by Validate.
+			profileErr: nil,
+		},
+		{
+			name:       "Invalid function",
+			meta:       "scoring_fn;none\n",
+			err:        InvalidMeta{Meta: "scoring_fn", Value: "none"},
+			profileErr: InvalidMeta{Meta: "scoring_fn", Value: "none"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := makeRuleData("ordinal", 10, []string{"a:1", "b:1", "c:1"}, [][]string{{"a,b,c"}, {"c"}})
+			pb := mustOpen(t, strings.Replace(data, "rule;greedy\n", "rule;greedy\n"+tt.meta, 1)).(OrdinalPB)
+
+			var err error
+			for i := 0; i < pb.CountVotes() && err == nil; i++ {
+				var scores map[string]float64
+				scores, err = pb.Scores(pb.Vote(i).(OrdinalVote))
+				if tt.err == nil && !reflect.DeepEqual(scores, tt.scores[i]) {
+					t.Errorf("Wrong scores for vote %d. Got %v. Expect %v.", i, scores, tt.scores[i])
+				}
+			}
+			if err != tt.err {
+				t.Errorf("Got error %v. Expect error %v.", err, tt.err)
+			}
+			if _, err := profile(pb); err != tt.profileErr {
+				t.Errorf("Got error %v from profile. Expect error %v.", err, tt.profileErr)
+			}
+		})
+	}
+}
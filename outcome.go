@@ -0,0 +1,163 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	UnsupportedVoteType = errors.New("Vote type not supported by the rule")
+)
+
+// Outcome is the set of projects funded by a rule.
+type Outcome struct {
+	// The identifiers of the selected projects, in selection order.
+	Selected []string
+	// The sum of the costs of the selected projects.
+	TotalCost int
+	// The part of the budget not spent. It is negative if the selected projects
+	// exceed the budget.
+	Leftover int
+
+	// The steps of the computation, for rules recording them.
+	Trace []Step
+}
+
+// Step records the examination of one project by a sequential rule.
+type Step struct {
+	Project string
+	// The value according to which the project has been chosen.
+	Score float64
+	Cost  int
+	// Whether the project has been selected.
+	Selected bool
+	// The budget left after this step.
+	Remaining int
+}
+
+// newOutcome builds an outcome from the indexes of the selected projects.
+func newOutcome(pb PB, selected []int) Outcome {
+	ret := Outcome{Selected: make([]string, len(selected))}
+	for i, index := range selected {
+		project := pb.ProjectByIndex(index)
+		ret.Selected[i] = project.Id()
+		ret.TotalCost += project.Cost()
+	}
+	ret.Leftover = pb.Budget() - ret.TotalCost
+	return ret
+}
+
+// SelectedOutcome returns the outcome recorded in the selected field of the
+// projects, in file order. Projects are selected if that field is a positive
+// integer. An empty field means the project is not selected. A
+// MissingRequiredField error is returned if the field is absent, and an
+// InvalidField error if it is not an integer.
+func SelectedOutcome(pb PB) (ret Outcome, err error) {
+	var selected []int
+	for i := 0; i < pb.CountProjects(); i++ {
+		str, ok := pb.ProjectByIndex(i).Field("selected")
+		if !ok {
+			return ret, MissingRequiredField{"selected"}
+		}
+		if str == "" {
+			continue
+		}
+		value, convErr := strconv.Atoi(str)
+		if convErr != nil {
+			return ret, InvalidField{Section: "PROJECTS", Line: i, Field: "selected", Value: str}
+		}
+		if value > 0 {
+			selected = append(selected, i)
+		}
+	}
+	return newOutcome(pb, selected), nil
+}
+
+// Comparison describes the differences between two outcomes.
+type Comparison struct {
+	// The projects selected by both outcomes, in the order of the first one.
+	Common []string
+	// The projects selected only by the first outcome, in its order.
+	OnlyFirst []string
+	// The projects selected only by the second outcome, in its order.
+	OnlySecond []string
+	// The number of projects selected by both outcomes divided by the number of
+	// projects selected by any of them. It is 1 if both outcomes are empty.
+	Overlap float64
+	// The total cost of the projects in OnlyFirst and OnlySecond.
+	OnlyFirstCost  int
+	OnlySecondCost int
+	// The total cost of the second outcome minus the total cost of the first.
+	CostDifference int
+}
+
+// Compare compares two outcomes of the same PB. Costs are taken from the PB,
+// projects unknown to it costing nothing.
+func Compare(pb PB, first, second Outcome) (ret Comparison) {
+	cost := func(id string) int {
+		if project, ok := pb.Project(id); ok {
+			return project.Cost()
+		}
+		return 0
+	}
+	firstIds, secondIds := uniqueIds(first.Selected), uniqueIds(second.Selected)
+	inFirst := make(map[string]bool, len(firstIds))
+	for _, id := range firstIds {
+		inFirst[id] = true
+	}
+	inSecond := make(map[string]bool, len(secondIds))
+	for _, id := range secondIds {
+		inSecond[id] = true
+	}
+
+	for _, id := range firstIds {
+		if inSecond[id] {
+			ret.Common = append(ret.Common, id)
+		} else {
+			ret.OnlyFirst = append(ret.OnlyFirst, id)
+			ret.OnlyFirstCost += cost(id)
+		}
+	}
+	for _, id := range secondIds {
+		if !inFirst[id] {
+			ret.OnlySecond = append(ret.OnlySecond, id)
+			ret.OnlySecondCost += cost(id)
+		}
+	}
+
+	ret.Overlap = 1
+	if union := len(ret.Common) + len(ret.OnlyFirst) + len(ret.OnlySecond); union > 0 {
+		ret.Overlap = float64(len(ret.Common)) / float64(union)
+	}
+	ret.CostDifference = ret.OnlySecondCost - ret.OnlyFirstCost
+	return
+}
+
+// uniqueIds returns the identifiers without repetition, in order.
+func uniqueIds(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	ret := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			ret = append(ret, id)
+		}
+	}
+	return ret
+}
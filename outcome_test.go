@@ -0,0 +1,125 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectedOutcome(t *testing.T) {
+	tests := []struct {
+		name     string
+		projects string
+		expect   Outcome
+		err      error
+	}{
+		{
+			name:     "Simple",
+			projects: "project_id;cost;selected\na;60;1\nb;40;0\nc;30;\nd;10;1\n",
+			expect:   Outcome{Selected: []string{"a", "d"}, TotalCost: 70, Leftover: 30},
+		},
+		{
+			name:     "Over budget",
+			projects: "project_id;cost;selected\na;60;1\nb;50;1\n",
+			expect:   Outcome{Selected: []string{"a", "b"}, TotalCost: 110, Leftover: -10},
+		},
+		{
+			name:     "Missing",
+			projects: "project_id;cost\na;60\n",
+			err:      MissingRequiredField{"selected"},
+		},
+		{
+			name:     "Invalid",
+			projects: "project_id;cost;selected\na;60;1\nb;50;yes\n",
+			err:      InvalidField{Section: "PROJECTS", Line: 1, Field: "selected", Value: "yes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Replace the empty projects built by makeRuleData.
+			numProjects := strings.Count(tt.projects, "\n") - 1
+			data := strings.Replace(makeRuleData("approval", 100, make([]string, numProjects), nil),
+				"project_id;cost\n"+strings.Repeat("\n", numProjects), tt.projects, 1)
+			outcome, err := SelectedOutcome(mustOpen(t, data))
+			if tt.err != nil {
+				if err != tt.err {
+					t.Errorf("Got error %v. Expect error %v.", err, tt.err)
+				}
+				return
+			}
+			mustt(t, err)
+			if !reflect.DeepEqual(outcome, tt.expect) {
+				t.Errorf("Got %v. Expect %v.", outcome, tt.expect)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	pb := mustOpen(t, makeRuleData("approval", 100, []string{"a:60", "b:40", "c:30", "d:10"}, nil))
+	tests := []struct {
+		name   string
+		first  []string
+		second []string
+		expect Comparison
+	}{
+		{
+			name:   "Different",
+			first:  []string{"a", "b"},
+			second: []string{"c", "a", "d"},
+			expect: Comparison{
+				Common:         []string{"a"},
+				OnlyFirst:      []string{"b"},
+				OnlySecond:     []string{"c", "d"},
+				Overlap:        0.25,
+				OnlyFirstCost:  40,
+				OnlySecondCost: 40,
+			},
+		},
+		{
+			name:   "Same",
+			first:  []string{"a", "b"},
+			second: []string{"b", "a", "b"},
+			expect: Comparison{Common: []string{"a", "b"}, Overlap: 1},
+		},
+		{
+			name:   "Unknown project",
+			first:  []string{"a"},
+			second: []string{"x"},
+			expect: Comparison{
+				OnlyFirst:      []string{"a"},
+				OnlySecond:     []string{"x"},
+				OnlyFirstCost:  60,
+				CostDifference: -60,
+			},
+		},
+		{
+			name:   "Empty",
+			expect: Comparison{Overlap: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare(pb, Outcome{Selected: tt.first}, Outcome{Selected: tt.second})
+			if !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("Got %+v. Expect %+v.", got, tt.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,387 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// DefaultChunkLines is the default value of ParallelOptions.ChunkLines.
+const DefaultChunkLines = 4096
+
+type ParallelOptions struct {
+	// The number of goroutines parsing the votes. Zero means
+	// runtime.GOMAXPROCS(0).
+	Workers int
+	// The number of lines given to a goroutine at once. Zero means
+	// DefaultChunkLines.
+	ChunkLines int
+}
+
+// ReadFileParallel is like ReadFile, except that the records of the VOTES
+// section are split and checked by several goroutines. The result, including
+// the returned errors, is the same as the one of ReadFile. If the context is
+// canceled before the file has been read, its error is returned. Cancellation
+// is noticed between two reads from the io.Reader, and the io.Reader is not
+// used anymore once the function returns.
+//
+// The lines of the VOTES section are cut into chunks. A chunk ending inside a
+// quoted value is parsed again together with the following one, which is
+// fast only if such values are rare. The sections after VOTES are read
+// sequentially.
+func ReadFileParallel(ctx context.Context, in io.Reader, opts ParallelOptions) (*File, error) {
+	ret, _, err := readFileParallel(ctx, in, opts)
+	return ret, err
+}
+
+// readFileParallel implements ReadFileParallel. When the META section precedes
+// the VOTES section and gives a vote type with points, the points are also
+// checked by the goroutines, and the result of checkPoints is returned.
+func readFileParallel(ctx context.Context, in io.Reader, opts ParallelOptions) (ret *File, points *pointsCheck, err error) {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	if opts.ChunkLines <= 0 {
+		opts.ChunkLines = DefaultChunkLines
+	}
+
+	ret = &File{sections: make(map[string]*Section)}
+	scan := newLineScanner(in)
+	sectionTitle := ""
+
+	for {
+		if err = ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		for sectionTitle == "" {
+			if !scan.Scan() {
+				err = scan.Err()
+				return
+			}
+			sectionTitle = strings.TrimSpace(scan.Text())
+		}
+
+		if sectionTitle != "VOTES" {
+			var nextTitle string
+			var section *Section
+			if section, nextTitle, err = newSection(scan, sectionTitle); err != nil {
+				return
+			}
+			ret.sections[sectionTitle] = section
+			sectionTitle = nextTitle
+			continue
+		}
+
+		var reader *sectionReader
+		if reader, err = openSection(scan, sectionTitle); err != nil {
+			return
+		}
+		votes := parallelVotes{reader: reader, opts: opts}
+		if hasPoints(ret) {
+			if indexes, ok := reader.section.FieldIndexes([]string{"vote", "points"}); ok {
+				votes.points = &pointsCheck{indexes: indexes}
+				points = votes.points
+			}
+		}
+		if err = votes.run(ctx); err != nil {
+			return nil, nil, err
+		}
+		ret.sections[sectionTitle] = reader.section
+		sectionTitle = votes.nextTitle
+		scan.unread(votes.rest)
+	}
+}
+
+// hasPoints returns whether the vote type given by the META section of the
+// file requires points.
+func hasPoints(file *File) bool {
+	meta, ok := file.Get("META")
+	if !ok {
+		return false
+	}
+	for _, line := range meta.Lines {
+		if line[0] == "vote_type" {
+			voteType := parseVoteType(line[1])
+			return voteType == VoteTypeCumulative || voteType == VoteTypeScoring
+		}
+	}
+	return false
+}
+
+// OpenParallel is like Open but reads the file with ReadFileParallel.
+func OpenParallel(ctx context.Context, in io.Reader, opts ParallelOptions) (PB, error) {
+	file, points, err := readFileParallel(ctx, in, opts)
+	if err != nil {
+		return nil, err
+	}
+	if points == nil {
+		return NewPB(file)
+	}
+	return newPB(file, func(*pbBase) error {
+		return points.err
+	})
+}
+
+// pointsCheck is the result of checkPointsLine on all the votes.
+type pointsCheck struct {
+	// The indexes of the vote and points fields.
+	indexes []int
+	// The error for the first invalid vote, if any.
+	err error
+}
+
+// parallelVotes reads the records of the VOTES section in parallel.
+type parallelVotes struct {
+	reader *sectionReader
+	opts   ParallelOptions
+	// The check of the points, if they must be checked.
+	points *pointsCheck
+
+	// The title of the section following VOTES, if any.
+	nextTitle string
+	// The lines read after that title.
+	rest []string
+}
+
+type voteChunk struct {
+	// The number of the first line of the chunk.
+	first  int
+	lines  []string
+	result chan chunkResult
+}
+
+type chunkResult struct {
+	records [][]string
+	// The number of the line where each record starts.
+	lineNums []int
+	// The index in the chunk of the line following the end of the section, or
+	// -1 if the section does not end in the chunk.
+	end   int
+	title string
+	// The lines of a record not terminated at the end of the chunk.
+	carry []string
+	err   error
+	// The error of checkPointsLine for the first invalid record of the chunk,
+	// with the index of the record in the chunk.
+	pointsErr error
+}
+
+func (self *parallelVotes) run(parent context.Context) error {
+	ctx, cancel := context.WithCancel(parent)
+	var running sync.WaitGroup
+	defer func() {
+		cancel()
+		running.Wait()
+	}()
+
+	// The chunks are given to the workers through jobs, and to the assembling
+	// loop below in file order through ordered. Once the end of the section is
+	// found, stop is closed and the lines read but not sent are in unsent.
+	jobs := make(chan *voteChunk, self.opts.Workers)
+	ordered := make(chan *voteChunk, 2*self.opts.Workers)
+	stop := make(chan struct{})
+	var (
+		unsent  []string
+		readErr error
+	)
+	running.Add(1 + self.opts.Workers)
+	go func() {
+		defer running.Done()
+		defer close(ordered)
+		defer close(jobs)
+		unsent, readErr = self.readChunks(ctx, stop, jobs, ordered)
+	}()
+	for i := 0; i < self.opts.Workers; i++ {
+		go func() {
+			defer running.Done()
+			for chunk := range jobs {
+				select {
+				case <-ctx.Done():
+					chunk.result <- chunkResult{err: ctx.Err()}
+				case <-stop:
+					// The chunk is after the end of the section.
+					chunk.result <- chunkResult{}
+				default:
+					chunk.result <- self.parse(chunk.first, chunk.lines)
+				}
+			}
+		}()
+	}
+
+	var (
+		carry     []string
+		carryLine int
+		ended     bool
+	)
+	for {
+		var chunk *voteChunk
+		select {
+		case chunk = <-ordered:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if chunk == nil {
+			break
+		}
+		if ended {
+			self.rest = append(self.rest, chunk.lines...)
+			continue
+		}
+
+		var result chunkResult
+		select {
+		case result = <-chunk.result:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		parsed, parsedLine := chunk.lines, chunk.first
+		if carry != nil {
+			// The worker started in the middle of a record.
+			parsed, parsedLine = append(carry[:len(carry):len(carry)], chunk.lines...), carryLine
+			result = self.parse(parsedLine, parsed)
+			carry = nil
+		}
+		if result.err != nil {
+			return result.err
+		}
+
+		if result.pointsErr != nil && self.points.err == nil {
+			pointsErr := result.pointsErr.(InvalidField)
+			pointsErr.Line += len(self.reader.section.Lines)
+			self.points.err = pointsErr
+		}
+		self.reader.section.Lines = append(self.reader.section.Lines, result.records...)
+		self.reader.section.lineNums = append(self.reader.section.lineNums, result.lineNums...)
+		if result.end >= 0 {
+			ended = true
+			close(stop)
+			self.nextTitle = result.title
+			self.rest = append(self.rest, parsed[result.end:]...)
+		}
+		if result.carry != nil {
+			carry = result.carry
+			carryLine = parsedLine + len(parsed) - len(carry)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if readErr != nil {
+		return readErr
+	}
+	if carry != nil {
+		return self.reader.fail(nil, carryLine, 0, 0, strings.Join(carry, "\n"))
+	}
+	self.rest = append(self.rest, unsent...)
+	return nil
+}
+
+// readChunks cuts the remaining lines into chunks, sent to both channels,
+// until stop is closed. The lines read but not sent are returned.
+func (self *parallelVotes) readChunks(ctx context.Context, stop <-chan struct{}, jobs, ordered chan<- *voteChunk) ([]string, error) {
+	scan := self.reader.scan
+	for {
+		chunk := &voteChunk{
+			first:  scan.line + 1,
+			lines:  make([]string, 0, self.opts.ChunkLines),
+			result: make(chan chunkResult, 1),
+		}
+		for len(chunk.lines) < self.opts.ChunkLines && scan.Scan() {
+			chunk.lines = append(chunk.lines, scan.Text())
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			select {
+			case <-stop:
+				return chunk.lines, nil
+			default:
+			}
+		}
+		if len(chunk.lines) == 0 {
+			return nil, scan.Err()
+		}
+		select {
+		case ordered <- chunk:
+		case <-stop:
+			return chunk.lines, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		select {
+		case jobs <- chunk:
+		case <-stop:
+			// The lines of the chunk are received from ordered.
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// parse parses the records of the given lines, the first of which has the
+// given number.
+func (self *parallelVotes) parse(first int, lines []string) (ret chunkResult) {
+	ret.end = -1
+	nbFields := len(self.reader.section.Fields)
+	for i := 0; i < len(lines); i++ {
+		start := i
+		raw := lines[i]
+		var (
+			record   []string
+			complete bool
+			err      error
+		)
+		for {
+			trimmed := strings.TrimSuffix(raw, "\r")
+			if record, complete, err = splitRecord(trimmed); err != nil {
+				ret.err = self.reader.fail(err, first+start, 0, 0, trimmed)
+				return
+			}
+			if complete {
+				raw = trimmed
+				break
+			}
+			if i+1 == len(lines) {
+				ret.carry = lines[start:]
+				return
+			}
+			i += 1
+			raw += "\n" + lines[i]
+		}
+
+		switch len(record) {
+		case nbFields:
+			if self.points != nil && ret.pointsErr == nil {
+				ret.pointsErr = checkPointsLine(self.points.indexes, record, len(ret.records))
+			}
+			ret.records = append(ret.records, record)
+			ret.lineNums = append(ret.lineNums, first+start)
+		case 1:
+			ret.end, ret.title = i+1, record[0]
+			return
+		default:
+			ret.err = self.reader.fail(nil, first+start, nbFields, len(record), raw)
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,192 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadFileParallel(t *testing.T) {
+	header := "META\nkey;value\nbudget;1\nVOTES\nvoter_id;vote\n"
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "Empty", data: header},
+		{name: "Votes", data: header + "0;a\n1;b\n2;a,b\n3;\n4;c\n"},
+		{name: "No votes section", data: "foo\nkey;value\nbar;baz\n"},
+		{name: "Section after", data: header + "0;a\n1;b\n2;c\nfoo\nkey;value\nbar;baz\nflu;blu\n"},
+		{name: "Blank line", data: header + "0;a\n1;b\n\n\nfoo\nkey;value\nbar;baz\n"},
+		{name: "Multi-line values", data: header + "0;\"a\nb\nc\nd\"\n1;b\n2;\"c\"\n3;\"d\ne\"\nfoo\nkey;value\n"},
+		{name: "Carriage returns", data: strings.ReplaceAll(header, "\n", "\r\n") + "0;\"a\r\nb\"\r\n1;c\r\n"},
+		{name: "Error", data: header + "0;a\n1;b\n2;a;b\n3;c\n4;d;e\n"},
+		{name: "Multi-line error", data: header + "0;a\n1;\"b\nc\";d\n"},
+		{name: "Unclosed quote", data: header + "0;a\n1;\"b\nc\n"},
+		{name: "Text after quote", data: header + "0;a\n1;\"b\"c\n2;d\n"},
+		{name: "Error after", data: header + "0;a\nfoo\nkey;value\nbar;baz;flu\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect, expectErr := ReadFile(strings.NewReader(tt.data))
+			for _, opts := range []ParallelOptions{{}, {Workers: 1, ChunkLines: 1}, {Workers: 3, ChunkLines: 2}, {Workers: 2, ChunkLines: 3}} {
+				got, err := ReadFileParallel(context.Background(), strings.NewReader(tt.data), opts)
+				if !reflect.DeepEqual(err, expectErr) {
+					t.Errorf("Wrong error with %v. Got %v. Expect %v.", opts, err, expectErr)
+				}
+				if err == nil && !reflect.DeepEqual(got, expect) {
+					t.Errorf("Wrong file with %v. Got %v. Expect %v.", opts, got, expect)
+				}
+			}
+		})
+	}
+}
+
+func TestReadFileParallel_StopAfterVotes(t *testing.T) {
+	var data strings.Builder
+	data.WriteString("VOTES\nvoter_id;vote\n0;a\n1;b\nfoo\nkey;value\n")
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&data, "%d;a\n", i)
+	}
+	scan := newLineScanner(strings.NewReader(data.String()))
+	scan.Scan()
+	reader, err := openSection(scan, "VOTES")
+	mustt(t, err)
+
+	votes := parallelVotes{reader: reader, opts: ParallelOptions{Workers: 2, ChunkLines: 2}}
+	mustt(t, votes.run(context.Background()))
+	if votes.nextTitle != "foo" {
+		t.Errorf("Wrong next title. Got %s. Expect foo.", votes.nextTitle)
+	}
+	if len(votes.rest) > 100 {
+		t.Errorf("Read %d lines after the section.", len(votes.rest))
+	}
+	scan.unread(votes.rest)
+	if !scan.Scan() || scan.Text() != "key;value" || scan.line != 6 {
+		t.Errorf("Wrong line %d after the section: %s.", scan.line, scan.Text())
+	}
+}
+
+func TestOpenParallel(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "Approval",
+			data: makeRuleData("approval", 10, []string{"a:1", "b:1"}, [][]string{{"a"}, {"b"}, {"a,b"}}),
+		},
+		{
+			name: "Cumulative",
+			data: makeRuleData("cumulative", 10, []string{"a:1", "b:1"},
+				[][]string{{"a", "1"}, {"b", "2"}, {"a,b", "1,1"}}),
+		},
+		{
+			name: "Invalid points",
+			data: makeRuleData("cumulative", 10, []string{"a:1", "b:1"},
+				[][]string{{"a", "1"}, {"b", "2"}, {"a,b", "1"}, {"a", "x"}, {"b", "1,2"}}),
+		},
+		{
+			name: "Invalid scores",
+			data: makeRuleData("scoring", 10, []string{"a:1", "b:1"},
+				[][]string{{"a", "1"}, {"b", "2"}, {"a", "1"}, {"a", "1"}, {"a", "y"}}),
+		},
+		{
+			name: "Invalid cost",
+			data: makeRuleData("cumulative", 10, []string{"a:1", "b:x"},
+				[][]string{{"a", "1"}, {"b", "x"}}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect, expectErr := Open(strings.NewReader(tt.data))
+			for _, opts := range []ParallelOptions{{}, {Workers: 1, ChunkLines: 1}, {Workers: 3, ChunkLines: 2}} {
+				got, err := OpenParallel(context.Background(), strings.NewReader(tt.data), opts)
+				if err != expectErr {
+					t.Errorf("Wrong error with %v. Got %v. Expect %v.", opts, err, expectErr)
+				}
+				if err == nil && !reflect.DeepEqual(got, expect) {
+					t.Errorf("Wrong PB with %v. Got %v. Expect %v.", opts, got, expect)
+				}
+			}
+		})
+	}
+}
+
+func TestReadFileParallel_Cancel(t *testing.T) {
+	var data strings.Builder
+	data.WriteString("META\nkey;value\nbudget;1\nVOTES\nvoter_id;vote\n")
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&data, "%d;a\n", i)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ReadFileParallel(ctx, strings.NewReader(data.String()), ParallelOptions{ChunkLines: 10})
+	if err != context.Canceled {
+		t.Errorf("Got error %v. Expect %v.", err, context.Canceled)
+	}
+}
+
+// slowReader gives one line of a file at a time, after a delay.
+type slowReader struct {
+	lines []string
+	delay time.Duration
+}
+
+func (self *slowReader) Read(p []byte) (int, error) {
+	if len(self.lines) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(self.delay)
+	n := copy(p, self.lines[0])
+	self.lines[0] = self.lines[0][n:]
+	if self.lines[0] == "" {
+		self.lines = self.lines[1:]
+	}
+	return n, nil
+}
+
+func TestReadFileParallel_CancelWhileReading(t *testing.T) {
+	in := &slowReader{
+		lines: []string{"META\n", "key;value\n", "budget;1\n", "VOTES\n", "voter_id;vote\n"},
+		delay: time.Millisecond,
+	}
+	for i := 0; i < 10000; i++ {
+		in.lines = append(in.lines, fmt.Sprintf("%d;a\n", i))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := ReadFileParallel(ctx, in, ParallelOptions{})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Got error %v. Expect %v.", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Returned after %v.", elapsed)
+	}
+	left := len(in.lines)
+	time.Sleep(10 * in.delay)
+	if len(in.lines) != left {
+		t.Errorf("Reader used after return.")
+	}
+}
@@ -0,0 +1,125 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+type PhragmenOptions struct {
+	// Use exact rational arithmetic instead of floats.
+	Exact bool
+	// How ties are broken. Nil means file order.
+	Ties TieBreaker
+}
+
+type PhragmenOutcome struct {
+	Outcome
+
+	// The final load of each voter, in the order of the votes.
+	Loads []float64
+}
+
+// Phragmen computes the outcome of the sequential Phragmén rule on approval
+// votes. Each project is paid by its supporters so as to minimize the maximal
+// load of a voter, the load being the total amount of money a voter has paid.
+// At each step the project leading to the smallest new load is chosen. If it
+// does not fit in the remaining budget, it is discarded and the computation
+// goes on. Ties are broken by the tie breaker of the options. The score of each
+// step of the trace is the new load of the supporters.
+func Phragmen(pb PB, opts PhragmenOptions) (ret PhragmenOutcome, err error) {
+	if pb.VoteType() != VoteTypeApproval {
+		return ret, UnsupportedVoteType
+	}
+	ballots, err := profile(pb)
+	if err != nil {
+		return
+	}
+
+	nums := numbers{exact: opts.Exact}
+	ids := projectIds(pb)
+	costs := projectCosts(pb)
+	supporters := make([][]int, len(costs))
+	for voter, b := range ballots {
+		for _, project := range b.projects {
+			supporters[project] = append(supporters[project], voter)
+		}
+	}
+
+	loads := make([]number, len(ballots))
+	for i := range loads {
+		loads[i] = nums.int(0)
+	}
+	var candidates []int
+	for project, sup := range supporters {
+		if len(sup) > 0 {
+			candidates = append(candidates, project)
+		}
+	}
+
+	var (
+		selected []int
+		trace    []Step
+	)
+	remaining := pb.Budget()
+	for len(candidates) > 0 {
+		var (
+			tied     []int
+			bestLoad number
+		)
+		for _, project := range candidates {
+			load := nums.int(costs[project])
+			for _, voter := range supporters[project] {
+				load = load.Add(loads[voter])
+			}
+			load = load.Quo(nums.int(len(supporters[project])))
+			switch {
+			case len(tied) == 0 || load.Cmp(bestLoad) < 0:
+				tied, bestLoad = append(tied[:0], project), load
+			case load.Cmp(bestLoad) == 0:
+				tied = append(tied, project)
+			}
+		}
+		best := breakTie(pb, opts.Ties, tied)
+		for pos, project := range candidates {
+			if project == best {
+				candidates = append(candidates[:pos], candidates[pos+1:]...)
+				break
+			}
+		}
+
+		step := Step{
+			Project:  ids[best],
+			Score:    bestLoad.Float64(),
+			Cost:     costs[best],
+			Selected: costs[best] <= remaining,
+		}
+		if step.Selected {
+			remaining -= costs[best]
+			selected = append(selected, best)
+			for _, voter := range supporters[best] {
+				loads[voter] = bestLoad
+			}
+		}
+		step.Remaining = remaining
+		trace = append(trace, step)
+	}
+
+	ret.Outcome = newOutcome(pb, selected)
+	ret.Trace = trace
+	ret.Loads = make([]float64, len(loads))
+	for i, load := range loads {
+		ret.Loads[i] = load.Float64()
+	}
+	return
+}
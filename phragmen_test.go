@@ -0,0 +1,84 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPhragmen(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		opts     PhragmenOptions
+		selected []string
+		loads    []float64
+		trace    []string
+		err      error
+	}{
+		{
+			name: "Simple",
+			data: makeRuleData("approval", 100, []string{"a:60", "b:40", "c:30"},
+				[][]string{{"a"}, {"a"}, {"a"}, {"b,c"}}),
+			selected: []string{"a", "c"},
+			loads:    []float64{20, 20, 20, 30},
+			trace:    []string{"a", "c", "b"},
+		},
+		{
+			name: "Shared load",
+			data: makeRuleData("approval", 100, []string{"a:40", "b:30"},
+				[][]string{{"a"}, {"a,b"}}),
+			opts:     PhragmenOptions{Exact: true},
+			selected: []string{"a", "b"},
+			loads:    []float64{20, 50},
+			trace:    []string{"a", "b"},
+		},
+		{
+			name: "Cumulative",
+			data: makeRuleData("cumulative", 20, []string{"a:10", "b:10"},
+				[][]string{{"a,b", "1,9"}}),
+			err: UnsupportedVoteType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, err := Phragmen(mustOpen(t, tt.data), tt.opts)
+			if tt.err != nil {
+				if err != tt.err {
+					t.Errorf("Got error %v. Expect error %v.", err, tt.err)
+				}
+				return
+			}
+			mustt(t, err)
+
+			if !reflect.DeepEqual(outcome.Selected, tt.selected) {
+				t.Errorf("Wrong selection. Got %v. Expect %v.", outcome.Selected, tt.selected)
+			}
+			if !reflect.DeepEqual(outcome.Loads, tt.loads) {
+				t.Errorf("Wrong loads. Got %v. Expect %v.", outcome.Loads, tt.loads)
+			}
+			trace := make([]string, len(outcome.Trace))
+			for i, step := range outcome.Trace {
+				trace[i] = step.Project
+			}
+			if !reflect.DeepEqual(trace, tt.trace) {
+				t.Errorf("Wrong trace. Got %v. Expect %v.", trace, tt.trace)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type PriceabilityOptions struct {
+	// The budget of each voter. Zero means that any budget is allowed.
+	VoterBudget float64
+}
+
+// PriceSystem is a certificate of priceability.
+type PriceSystem struct {
+	// The budget of each voter.
+	VoterBudget float64
+	// The positive payments of each voter, in vote order, by project
+	// identifier. Voters with the same ballot share the same map.
+	Payments []map[string]float64
+}
+
+// Priceable checks whether the given projects are priceable for approval
+// votes. They are priceable if each voter can be given the same budget and
+// pay for approved selected projects only, such that the payments for each
+// selected project sum to its cost, no voter pays more than their budget, no
+// other project is paid, and for each project not selected, the money left to
+// its supporters is at most its cost.
+//
+// The check solves a linear program with one variable per group of voters
+// with the same ballot and selected project they approve. The returned price
+// system gives the same payments to voters with the same ballot. It is nil if
+// the projects are not priceable. An UnknownProject error is returned if a
+// selected project is not in the PB.
+func Priceable(pb PB, selected []string, opts PriceabilityOptions) (ret *PriceSystem, err error) {
+	if pb.VoteType() != VoteTypeApproval {
+		return nil, UnsupportedVoteType
+	}
+	ballots, err := profile(pb)
+	if err != nil {
+		return
+	}
+	isSelected, err := selectedIndexes(pb, selected)
+	if err != nil {
+		return
+	}
+	costs := projectCosts(pb)
+
+	// Group the voters by ballot.
+	type group struct {
+		approved []int
+		voters   []int
+		// The column of the first payment, one per approved selected project.
+		payments int
+	}
+	var groups []*group
+	byKey := make(map[string]*group)
+	for voter, b := range ballots {
+		approved := append([]int(nil), b.projects...)
+		sort.Ints(approved)
+		var key strings.Builder
+		unique := approved[:0]
+		for i, project := range approved {
+			if i == 0 || approved[i-1] != project {
+				unique = append(unique, project)
+				key.WriteString(strconv.Itoa(project))
+				key.WriteByte(',')
+			}
+		}
+		g, ok := byKey[key.String()]
+		if !ok {
+			g = &group{approved: unique}
+			byKey[key.String()] = g
+			groups = append(groups, g)
+		}
+		g.voters = append(g.voters, voter)
+	}
+
+	// Columns: the voter budget, the payments, the money left to each group,
+	// and the slack of each project not selected.
+	cols := 1
+	for _, g := range groups {
+		g.payments = cols
+		for _, project := range g.approved {
+			if isSelected[project] {
+				cols += 1
+			}
+		}
+	}
+	leftCol := cols
+	cols += len(groups)
+	slackCol := make(map[int]int)
+	for project := range costs {
+		if !isSelected[project] {
+			slackCol[project] = cols
+			cols += 1
+		}
+	}
+
+	var (
+		a [][]float64
+		b []float64
+	)
+	newRow := func(rhs float64) []float64 {
+		row := make([]float64, cols)
+		a = append(a, row)
+		b = append(b, rhs)
+		return row
+	}
+
+	if opts.VoterBudget > 0 {
+		newRow(opts.VoterBudget)[0] = 1
+	}
+	// The payments and the money left sum to the voter budget.
+	for i, g := range groups {
+		row := newRow(0)
+		row[0] = -1
+		col := g.payments
+		for _, project := range g.approved {
+			if isSelected[project] {
+				row[col] = 1
+				col += 1
+			}
+		}
+		row[leftCol+i] = 1
+	}
+	// Selected projects are paid, and the others are too expensive for their
+	// supporters.
+	rows := make([][]float64, len(costs))
+	for project, cost := range costs {
+		rows[project] = newRow(float64(cost))
+		if !isSelected[project] {
+			rows[project][slackCol[project]] = 1
+		}
+	}
+	for i, g := range groups {
+		col := g.payments
+		for _, project := range g.approved {
+			if isSelected[project] {
+				rows[project][col] = float64(len(g.voters))
+				col += 1
+			} else {
+				rows[project][leftCol+i] = float64(len(g.voters))
+			}
+		}
+	}
+
+	solution := lpFeasible(a, b)
+	if solution == nil {
+		return nil, nil
+	}
+
+	ret = &PriceSystem{
+		VoterBudget: solution[0],
+		Payments:    make([]map[string]float64, len(ballots)),
+	}
+	for _, g := range groups {
+		payments := make(map[string]float64)
+		col := g.payments
+		for _, project := range g.approved {
+			if isSelected[project] {
+				if solution[col] > lpTolerance {
+					payments[pb.ProjectByIndex(project).Id()] = solution[col]
+				}
+				col += 1
+			}
+		}
+		for _, voter := range g.voters {
+			ret.Payments[voter] = payments
+		}
+	}
+	return
+}
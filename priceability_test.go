@@ -0,0 +1,111 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPriceable(t *testing.T) {
+	data := makeRuleData("approval", 100, []string{"a:60", "b:40", "c:30", "d:10"},
+		[][]string{{"a"}, {"a"}, {"a"}, {"b,c"}})
+	tests := []struct {
+		name      string
+		data      string
+		selected  []string
+		opts      PriceabilityOptions
+		priceable bool
+		err       error
+	}{
+		{name: "Priceable", selected: []string{"a"}, priceable: true},
+		{name: "Too expensive", selected: []string{"b", "c"}},
+		{name: "Fixed budget", selected: []string{"a"}, opts: PriceabilityOptions{VoterBudget: 25}, priceable: true},
+		{name: "Leftover too large", selected: []string{"a"}, opts: PriceabilityOptions{VoterBudget: 35}},
+		{name: "No supporter", selected: []string{"a", "d"}},
+		{name: "Unknown project", selected: []string{"x"}, err: UnknownProject{"x"}},
+		{
+			name: "Cumulative",
+			data: makeRuleData("cumulative", 10, []string{"a:5"}, [][]string{{"a", "1"}}),
+			err:  UnsupportedVoteType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.data == "" {
+				tt.data = data
+			}
+			pb := mustOpen(t, tt.data)
+			ps, err := Priceable(pb, tt.selected, tt.opts)
+			if tt.err != nil {
+				if err != tt.err {
+					t.Errorf("Got error %v. Expect error %v.", err, tt.err)
+				}
+				return
+			}
+			mustt(t, err)
+			if (ps != nil) != tt.priceable {
+				t.Fatalf("Got price system %v. Expect priceable %t.", ps, tt.priceable)
+			}
+			if ps == nil {
+				return
+			}
+			checkPriceSystem(t, pb, tt.selected, ps)
+			if tt.opts.VoterBudget > 0 && !sameWelfare(ps.VoterBudget, tt.opts.VoterBudget) {
+				t.Errorf("Wrong voter budget. Got %f. Expect %f.", ps.VoterBudget, tt.opts.VoterBudget)
+			}
+		})
+	}
+}
+
+// checkPriceSystem verifies the conditions of priceability.
+func checkPriceSystem(t *testing.T, pb PB, selected []string, ps *PriceSystem) {
+	isSelected := make(map[string]bool)
+	for _, id := range selected {
+		isSelected[id] = true
+	}
+	paid := make(map[string]float64)
+	supporterLeft := make(map[string]float64)
+	for voter := 0; voter < pb.CountVotes(); voter++ {
+		vote := pb.Vote(voter).(ApprovalVote)
+		left := ps.VoterBudget
+		for id, payment := range ps.Payments[voter] {
+			if !isSelected[id] || !vote.Approves(id) {
+				t.Errorf("Voter %d pays for %s.", voter, id)
+			}
+			paid[id] += payment
+			left -= payment
+		}
+		if left < -lpTolerance {
+			t.Errorf("Voter %d pays more than their budget.", voter)
+		}
+		for _, id := range vote.Vote {
+			supporterLeft[id] += left
+		}
+	}
+	for i := 0; i < pb.CountProjects(); i++ {
+		project := pb.ProjectByIndex(i)
+		cost := float64(project.Cost())
+		if isSelected[project.Id()] {
+			if math.Abs(paid[project.Id()]-cost) > 1e-6 {
+				t.Errorf("Project %s paid %f instead of %f.", project.Id(), paid[project.Id()], cost)
+			}
+		} else if supporterLeft[project.Id()] > cost+1e-6 {
+			t.Errorf("Supporters of %s have %f left.", project.Id(), supporterLeft[project.Id()])
+		}
+	}
+}
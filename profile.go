@@ -0,0 +1,146 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+// ballot is the compact representation of a vote used by rules. Projects are
+// identified by their index in the PB. Projects not in the ballot have zero
+// utility.
+type ballot struct {
+	projects  []int
+	utilities []float64
+}
+
+// profile converts all the votes of the PB into ballots. Approved projects
+// have utility 1, cumulative and scoring votes give their points as utility,
+// including the default score, and ordinal votes give the score of each ranked
+// project according to the scoring function of the PB. Projects unknown to the
+// PB and projects with a zero score are ignored. Ordinal votes shorter than
+// min_length are kept, since that is reported by Validate.
+func profile(pb PB) ([]ballot, error) {
+	numProjects := pb.CountProjects()
+	ret := make([]ballot, pb.CountVotes())
+
+	var (
+		scoringFn ScoringFunction
+		maxLength int
+	)
+	if ordinal, ok := pb.(OrdinalPB); ok {
+		var err error
+		if scoringFn, err = ordinal.ScoringFunction(); err != nil {
+			return nil, err
+		}
+		maxLength = ordinal.MaxLength()
+	}
+
+	ids := projectIds(pb)
+	index := make(map[string]int, numProjects)
+	for i, id := range ids {
+		index[id] = i
+	}
+
+	for i := range ret {
+		b := &ret[i]
+		add := func(id string, utility float64) {
+			if project, ok := index[id]; ok {
+				b.projects = append(b.projects, project)
+				b.utilities = append(b.utilities, utility)
+			}
+		}
+
+		switch vote := pb.Vote(i).(type) {
+		case ApprovalVote:
+			for _, id := range vote.Vote {
+				add(id, 1)
+			}
+		case OrdinalVote:
+			if scoringFn == nil {
+				return nil, UnsupportedPB
+			}
+			scores, err := vote.scores(scoringFn, 0, maxLength)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range vote.Vote {
+				if score, ok := scores[id]; ok && score != 0 {
+					add(id, score)
+					delete(scores, id)
+				}
+			}
+		case CumulativeVote:
+			for _, pp := range vote.Vote {
+				add(pp.Project, float64(pp.Points))
+			}
+		case ScoringVote:
+			for _, id := range ids {
+				if score := vote.Score(id); score != 0 {
+					add(id, float64(score))
+				}
+			}
+		default:
+			return nil, UnsupportedPB
+		}
+	}
+	return ret, nil
+}
+
+// projectIds returns the identifier of each project of the PB.
+func projectIds(pb PB) []string {
+	ret := make([]string, pb.CountProjects())
+	for i := range ret {
+		ret[i] = pb.ProjectByIndex(i).Id()
+	}
+	return ret
+}
+
+// projectCosts returns the cost of each project of the PB.
+func projectCosts(pb PB) []int {
+	ret := make([]int, pb.CountProjects())
+	for i := range ret {
+		ret[i] = pb.ProjectByIndex(i).Cost()
+	}
+	return ret
+}
+
+// totalUtilities returns the sum of the utilities of each project.
+func totalUtilities(numProjects int, ballots []ballot) []float64 {
+	ret := make([]float64, numProjects)
+	for _, b := range ballots {
+		for i, project := range b.projects {
+			ret[project] += b.utilities[i]
+		}
+	}
+	return ret
+}
+
+// selectedIndexes returns whether each project is in the given list of
+// identifiers. An UnknownProject error is returned if an identifier is not in
+// the PB.
+func selectedIndexes(pb PB, selected []string) ([]bool, error) {
+	index := make(map[string]int, pb.CountProjects())
+	for i, id := range projectIds(pb) {
+		index[id] = i
+	}
+	ret := make([]bool, pb.CountProjects())
+	for _, id := range selected {
+		project, ok := index[id]
+		if !ok {
+			return nil, UnknownProject{id}
+		}
+		ret[project] = true
+	}
+	return ret, nil
+}
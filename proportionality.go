@@ -0,0 +1,394 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Satisfaction functions, measuring how much a voter likes a set of projects.
+const (
+	// The number of approved projects in the set.
+	SatisfactionCardinality = iota
+	// The total cost of the approved projects in the set.
+	SatisfactionCost
+)
+
+type ProportionalityOptions struct {
+	Satisfaction int
+	// Decide EJR and PJR by enumerating the sets of projects, which takes
+	// exponential time in the worst case. Otherwise, EJR and PJR are only
+	// decided when a violation of JR implies their violation.
+	Exact bool
+}
+
+// Witness is a cohesive group of voters that an outcome does not represent
+// enough.
+type Witness struct {
+	// The identifiers of the voters.
+	Voters []string
+	// Projects approved by all the voters, whose total cost the voters can pay
+	// with their share of the budget.
+	Projects []string
+}
+
+type AxiomCheck struct {
+	// Whether the axiom holds. Only meaningful if Decided is true.
+	Holds bool
+	// Whether the check has been conclusive.
+	Decided bool
+	// The group violating the axiom, if it does not hold.
+	Witness *Witness
+}
+
+type ProportionalityReport struct {
+	JR  AxiomCheck
+	PJR AxiomCheck
+	EJR AxiomCheck
+}
+
+// CheckProportionality checks whether the given projects satisfy Justified
+// Representation (JR), Proportional Justified Representation (PJR) and
+// Extended Justified Representation (EJR), for approval votes.
+//
+// A group of voters is T-cohesive, for a set T of projects, if all voters of
+// the group approve all projects of T and the cost of T is at most the size of
+// the group divided by the number of voters times the budget. EJR requires
+// that in each T-cohesive group, some voter has a satisfaction at least the
+// satisfaction of T. PJR requires that the satisfaction given by the selected
+// projects approved by at least one voter of each T-cohesive group is at least
+// the satisfaction of T. JR is EJR restricted to sets of one project, and is
+// checked in polynomial time.
+//
+// An UnknownProject error is returned if a selected project is not in the PB.
+func CheckProportionality(pb PB, selected []string, opts ProportionalityOptions) (
+	ret ProportionalityReport, err error,
+) {
+	instance, err := newPropInstance(pb, selected, opts)
+	if err != nil {
+		return
+	}
+
+	ret.JR = instance.check(instance.searchJR())
+	if opts.Exact {
+		ret.EJR = instance.check(instance.searchEJR(nil, instance.allVoters(), 0))
+		ret.PJR = instance.check(instance.searchPJR(nil, instance.allVoters(), 0))
+		return
+	}
+	if !ret.JR.Holds {
+		ret.EJR = ret.JR
+		if opts.Satisfaction == SatisfactionCardinality {
+			// No voter of the group has a selected project.
+			ret.PJR = ret.JR
+		}
+	}
+	return
+}
+
+type propInstance struct {
+	pb         PB
+	opts       ProportionalityOptions
+	budget     int
+	numVoters  int
+	costs      []int
+	supporters [][]int
+	// The selected projects approved by each voter.
+	represented [][]int
+	// The satisfaction of each voter.
+	satisfaction []int
+}
+
+func newPropInstance(pb PB, selected []string, opts ProportionalityOptions) (ret *propInstance, err error) {
+	if pb.VoteType() != VoteTypeApproval {
+		return nil, UnsupportedVoteType
+	}
+	ballots, err := profile(pb)
+	if err != nil {
+		return
+	}
+
+	ret = &propInstance{
+		pb:           pb,
+		opts:         opts,
+		budget:       pb.Budget(),
+		numVoters:    len(ballots),
+		costs:        projectCosts(pb),
+		supporters:   make([][]int, pb.CountProjects()),
+		represented:  make([][]int, len(ballots)),
+		satisfaction: make([]int, len(ballots)),
+	}
+
+	isSelected, err := selectedIndexes(pb, selected)
+	if err != nil {
+		return nil, err
+	}
+
+	for voter, b := range ballots {
+		approved := append([]int(nil), b.projects...)
+		sort.Ints(approved)
+		for i, project := range approved {
+			if i > 0 && approved[i-1] == project {
+				continue
+			}
+			ret.supporters[project] = append(ret.supporters[project], voter)
+			if isSelected[project] {
+				ret.represented[voter] = append(ret.represented[voter], project)
+			}
+		}
+		ret.satisfaction[voter] = ret.sat(ret.represented[voter])
+	}
+	return
+}
+
+func (self *propInstance) sat(projects []int) int {
+	if self.opts.Satisfaction == SatisfactionCardinality {
+		return len(projects)
+	}
+	ret := 0
+	for _, project := range projects {
+		ret += self.costs[project]
+	}
+	return ret
+}
+
+// cohesive returns whether a group of the given size can pay the given cost.
+func (self *propInstance) cohesive(size, cost int) bool {
+	return size > 0 && size*self.budget >= cost*self.numVoters
+}
+
+func (self *propInstance) allVoters() []int {
+	ret := make([]int, self.numVoters)
+	for i := range ret {
+		ret[i] = i
+	}
+	return ret
+}
+
+func (self *propInstance) check(witness *Witness) AxiomCheck {
+	return AxiomCheck{Holds: witness == nil, Decided: true, Witness: witness}
+}
+
+func (self *propInstance) witness(voters, projects []int) *Witness {
+	ret := &Witness{
+		Voters:   make([]string, len(voters)),
+		Projects: make([]string, len(projects)),
+	}
+	for i, voter := range voters {
+		ret.Voters[i] = self.pb.Vote(voter).Id()
+	}
+	for i, project := range projects {
+		ret.Projects[i] = self.pb.ProjectByIndex(project).Id()
+	}
+	return ret
+}
+
+// unsatisfied returns the voters whose satisfaction is less than the given one.
+func (self *propInstance) unsatisfied(voters []int, need int) (ret []int) {
+	for _, voter := range voters {
+		if self.satisfaction[voter] < need {
+			ret = append(ret, voter)
+		}
+	}
+	return
+}
+
+// searchJR returns a violation of JR, if any. Such a violation exists if the
+// unsatisfied supporters of some project can pay for it.
+func (self *propInstance) searchJR() *Witness {
+	for project, supporters := range self.supporters {
+		group := self.unsatisfied(supporters, self.sat([]int{project}))
+		if self.cohesive(len(group), self.costs[project]) {
+			return self.witness(group, []int{project})
+		}
+	}
+	return nil
+}
+
+// extensions calls fn for each set of projects obtained by adding to projects
+// a project of higher index, such that the voters approving all of them can
+// pay for them, and recursively for each extension, until fn returns a
+// witness.
+func (self *propInstance) extensions(projects, voters []int, cost int,
+	fn func(projects, voters []int, cost int) *Witness,
+) *Witness {
+	first := 0
+	if len(projects) > 0 {
+		first = projects[len(projects)-1] + 1
+	}
+	for project := first; project < len(self.costs); project++ {
+		group := intersectSorted(voters, self.supporters[project])
+		extCost := cost + self.costs[project]
+		// Adding projects only increases the cost and shrinks the group.
+		if !self.cohesive(len(group), extCost) {
+			continue
+		}
+		extended := append(projects[:len(projects):len(projects)], project)
+		if ret := fn(extended, group, extCost); ret != nil {
+			return ret
+		}
+		if ret := self.extensions(extended, group, extCost, fn); ret != nil {
+			return ret
+		}
+	}
+	return nil
+}
+
+// searchEJR returns a violation of EJR, if any. Such a violation exists for a
+// set T of projects if the unsatisfied voters approving all of T can pay for
+// T.
+func (self *propInstance) searchEJR(projects, voters []int, cost int) *Witness {
+	return self.extensions(projects, voters, cost, func(projects, voters []int, cost int) *Witness {
+		group := self.unsatisfied(voters, self.sat(projects))
+		if self.cohesive(len(group), cost) {
+			return self.witness(group, projects)
+		}
+		return nil
+	})
+}
+
+// searchPJR returns a violation of PJR, if any. For each set T of projects,
+// the voters approving all of T are grouped by the selected projects they
+// approve, and a set of groups large enough to pay for T but whose selected
+// projects give less satisfaction than T is searched for.
+func (self *propInstance) searchPJR(projects, voters []int, cost int) *Witness {
+	return self.extensions(projects, voters, cost, func(projects, voters []int, cost int) *Witness {
+		need := self.sat(projects)
+		search := pjrSearch{instance: self, need: need}
+		byKey := make(map[string]int)
+		for _, voter := range voters {
+			if self.satisfaction[voter] >= need {
+				continue
+			}
+			var key strings.Builder
+			for _, project := range self.represented[voter] {
+				key.WriteString(strconv.Itoa(project))
+				key.WriteByte(',')
+			}
+			pos, ok := byKey[key.String()]
+			if !ok {
+				pos = len(search.classes)
+				byKey[key.String()] = pos
+				search.classes = append(search.classes, pjrClass{represented: self.represented[voter]})
+			}
+			search.classes[pos].voters = append(search.classes[pos].voters, voter)
+		}
+		sort.SliceStable(search.classes, func(i, j int) bool {
+			return len(search.classes[i].voters) > len(search.classes[j].voters)
+		})
+
+		search.suffix = make([]int, len(search.classes)+1)
+		for i := len(search.classes) - 1; i >= 0; i-- {
+			search.suffix[i] = search.suffix[i+1] + len(search.classes[i].voters)
+		}
+		search.covered = make(map[int]int)
+		if !search.search(0, 0, cost) {
+			return nil
+		}
+
+		var group []int
+		for _, class := range search.chosen {
+			group = append(group, class.voters...)
+		}
+		sort.Ints(group)
+		return self.witness(group, projects)
+	})
+}
+
+// pjrClass is a set of voters approving the same selected projects.
+type pjrClass struct {
+	represented []int
+	voters      []int
+}
+
+type pjrSearch struct {
+	instance *propInstance
+	need     int
+	classes  []pjrClass
+	// suffix[i] is the number of voters in classes i and after.
+	suffix []int
+	// The number of chosen classes approving each selected project.
+	covered map[int]int
+	chosen  []pjrClass
+}
+
+// search returns whether some classes from position pos, added to the chosen
+// ones, form a group able to pay the cost while the selected projects they
+// approve give a satisfaction less than need.
+func (self *pjrSearch) search(pos, size, cost int) bool {
+	if self.instance.cohesive(size, cost) {
+		return true
+	}
+	if !self.instance.cohesive(size+self.suffix[pos], cost) {
+		return false
+	}
+
+	for i := pos; i < len(self.classes); i++ {
+		class := self.classes[i]
+		var added []int
+		for _, project := range class.represented {
+			if self.covered[project] == 0 {
+				added = append(added, project)
+			}
+		}
+		if self.satisfaction()+self.instance.sat(added) >= self.need {
+			continue
+		}
+
+		for _, project := range class.represented {
+			self.covered[project] += 1
+		}
+		self.chosen = append(self.chosen, class)
+		if self.search(i+1, size+len(class.voters), cost) {
+			return true
+		}
+		self.chosen = self.chosen[:len(self.chosen)-1]
+		for _, project := range class.represented {
+			self.covered[project] -= 1
+		}
+	}
+	return false
+}
+
+// satisfaction returns the satisfaction given by the selected projects
+// approved by the chosen classes.
+func (self *pjrSearch) satisfaction() int {
+	projects := make([]int, 0, len(self.covered))
+	for project, count := range self.covered {
+		if count > 0 {
+			projects = append(projects, project)
+		}
+	}
+	return self.instance.sat(projects)
+}
+
+// intersectSorted returns the elements common to two sorted slices.
+func intersectSorted(a, b []int) (ret []int) {
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i += 1
+		case a[i] > b[j]:
+			j += 1
+		default:
+			ret = append(ret, a[i])
+			i, j = i+1, j+1
+		}
+	}
+	return
+}
@@ -0,0 +1,145 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckProportionality(t *testing.T) {
+	// Voters 0 and 1 deserve both a and b, but get one project each.
+	spread := makeRuleData("approval", 200, []string{"a:50", "b:50", "c:50", "d:50", "e:100"},
+		[][]string{{"a,b,c"}, {"a,b,d"}, {"e"}, {"e"}})
+	// Voters 0 and 1 deserve both a and b, but get only a.
+	partial := makeRuleData("approval", 200, []string{"a:50", "b:50", "c:100", "d:100"},
+		[][]string{{"a,b"}, {"b,a"}, {"c"}, {"d"}})
+
+	holds := AxiomCheck{Holds: true, Decided: true}
+	violated := func(voters, projects []string) AxiomCheck {
+		return AxiomCheck{Decided: true, Witness: &Witness{Voters: voters, Projects: projects}}
+	}
+
+	tests := []struct {
+		name     string
+		data     string
+		selected []string
+		opts     ProportionalityOptions
+		expect   ProportionalityReport
+		err      error
+	}{
+		{
+			name:     "JR violated",
+			data:     partial,
+			selected: []string{"c", "d"},
+			expect: ProportionalityReport{
+				JR:  violated([]string{"0", "1"}, []string{"a"}),
+				PJR: violated([]string{"0", "1"}, []string{"a"}),
+				EJR: violated([]string{"0", "1"}, []string{"a"}),
+			},
+		},
+		{
+			name:     "JR violated cost",
+			data:     partial,
+			selected: []string{"c", "d"},
+			opts:     ProportionalityOptions{Satisfaction: SatisfactionCost},
+			expect: ProportionalityReport{
+				JR:  violated([]string{"0", "1"}, []string{"a"}),
+				EJR: violated([]string{"0", "1"}, []string{"a"}),
+			},
+		},
+		{
+			name:     "Undecided",
+			data:     partial,
+			selected: []string{"a", "c"},
+			expect:   ProportionalityReport{JR: holds},
+		},
+		{
+			name:     "PJR violated",
+			data:     partial,
+			selected: []string{"a", "c"},
+			opts:     ProportionalityOptions{Exact: true},
+			expect: ProportionalityReport{
+				JR:  holds,
+				PJR: violated([]string{"0", "1"}, []string{"a", "b"}),
+				EJR: violated([]string{"0", "1"}, []string{"a", "b"}),
+			},
+		},
+		{
+			name:     "EJR violated",
+			data:     spread,
+			selected: []string{"c", "d", "e"},
+			opts:     ProportionalityOptions{Exact: true},
+			expect: ProportionalityReport{
+				JR:  holds,
+				PJR: holds,
+				EJR: violated([]string{"0", "1"}, []string{"a", "b"}),
+			},
+		},
+		{
+			name:     "EJR violated cost",
+			data:     spread,
+			selected: []string{"c", "d", "e"},
+			opts:     ProportionalityOptions{Exact: true, Satisfaction: SatisfactionCost},
+			expect: ProportionalityReport{
+				JR:  holds,
+				PJR: holds,
+				EJR: violated([]string{"0", "1"}, []string{"a", "b"}),
+			},
+		},
+		{
+			name:     "All hold",
+			data:     spread,
+			selected: []string{"a", "b", "e"},
+			opts:     ProportionalityOptions{Exact: true},
+			expect:   ProportionalityReport{JR: holds, PJR: holds, EJR: holds},
+		},
+		{
+			name:     "Unknown project",
+			data:     spread,
+			selected: []string{"a", "x"},
+			err:      UnknownProject{"x"},
+		},
+		{
+			name: "Cumulative",
+			data: makeRuleData("cumulative", 10, []string{"a:5"}, [][]string{{"a", "1"}}),
+			err:  UnsupportedVoteType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := CheckProportionality(mustOpen(t, tt.data), tt.selected, tt.opts)
+			if tt.err != nil {
+				if err != tt.err {
+					t.Errorf("Got error %v. Expect error %v.", err, tt.err)
+				}
+				return
+			}
+			mustt(t, err)
+			for name, pair := range map[string][2]AxiomCheck{
+				"JR":  {report.JR, tt.expect.JR},
+				"PJR": {report.PJR, tt.expect.PJR},
+				"EJR": {report.EJR, tt.expect.EJR},
+			} {
+				if !reflect.DeepEqual(pair[0], pair[1]) {
+					t.Errorf("Wrong %s. Got %+v (%+v). Expect %+v (%+v).",
+						name, pair[0], pair[0].Witness, pair[1], pair[1].Witness)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+type UnknownRule struct {
+	Rule string
+}
+
+func (self UnknownRule) Error() string {
+	return fmt.Sprintf("Unknown rule %s", self.Rule)
+}
+
+// RuleFunc computes the outcome of a rule on a PB, breaking ties with the given
+// tie breaker. A nil tie breaker means the default behaviour of the rule.
+type RuleFunc func(pb PB, ties TieBreaker) (Outcome, error)
+
+var (
+	rulesMutex sync.RWMutex
+	rules      = make(map[string]RuleFunc)
+)
+
+func init() {
+	RegisterRule(RuleNameGreedy, func(pb PB, ties TieBreaker) (Outcome, error) {
+		return Greedy(pb, GreedyOptions{Ties: ties})
+	})
+	RegisterRule(RuleNameUnitCost, func(pb PB, ties TieBreaker) (Outcome, error) {
+		return Greedy(pb, GreedyOptions{PerCost: true, Ties: ties})
+	})
+	RegisterRule(RuleNameEqualShares, func(pb PB, ties TieBreaker) (Outcome, error) {
+		return EqualShares(pb, MESOptions{Ties: ties})
+	})
+	RegisterRule(RuleNameCondorcet, func(pb PB, ties TieBreaker) (Outcome, error) {
+		return Condorcet(pb, CondorcetOptions{Ties: ties})
+	})
+}
+
+// RegisterRule makes a rule available under the given name, which is the value
+// of the rule meta key of files using that rule. It panics if the rule is nil
+// or if a rule is already registered under that name.
+func RegisterRule(name string, rule RuleFunc) {
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+	if rule == nil {
+		panic("pabulib: RegisterRule with nil rule")
+	}
+	if _, dup := rules[name]; dup {
+		panic("pabulib: RegisterRule called twice for rule " + name)
+	}
+	rules[name] = rule
+}
+
+// unregisterRule removes a rule from the registry.
+func unregisterRule(name string) {
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+	delete(rules, name)
+}
+
+// LookupRule returns the rule registered under the given name.
+func LookupRule(name string) (rule RuleFunc, ok bool) {
+	rulesMutex.RLock()
+	defer rulesMutex.RUnlock()
+	rule, ok = rules[name]
+	return
+}
+
+// Rules returns the names of all registered rules, sorted.
+func Rules() []string {
+	rulesMutex.RLock()
+	defer rulesMutex.RUnlock()
+	ret := make([]string, 0, len(rules))
+	for name := range rules {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Compute computes the outcome of the rule declared by the PB, breaking ties
+// with the given tie breaker, which may be nil. An UnknownRule error is
+// returned if no rule is registered under that name.
+func Compute(pb PB, ties TieBreaker) (Outcome, error) {
+	rule, ok := LookupRule(pb.Rule())
+	if !ok {
+		return Outcome{}, UnknownRule{pb.Rule()}
+	}
+	return rule(pb, ties)
+}
+
+// ComputeAll computes all the outcomes of the rule declared by the PB, one for
+// each way to break the ties. See EnumerateTies.
+func ComputeAll(pb PB) ([]Outcome, error) {
+	rule, ok := LookupRule(pb.Rule())
+	if !ok {
+		return nil, UnknownRule{pb.Rule()}
+	}
+	return EnumerateTies(func(ties TieBreaker) (Outcome, error) {
+		return rule(pb, ties)
+	})
+}
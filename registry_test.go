@@ -0,0 +1,65 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompute(t *testing.T) {
+	RegisterRule("test-first", func(pb PB, ties TieBreaker) (Outcome, error) {
+		return newOutcome(pb, []int{0}), nil
+	})
+	defer unregisterRule("test-first")
+
+	data := makeRuleData("approval", 100, []string{"a:60", "b:40", "c:30"},
+		[][]string{{"a"}, {"a"}, {"a"}, {"b,c"}})
+	tests := []struct {
+		name     string
+		rule     string
+		selected []string
+		err      error
+	}{
+		{name: "Greedy", rule: RuleNameGreedy, selected: []string{"a", "b"}},
+		{name: "Unit cost", rule: RuleNameUnitCost, selected: []string{"a", "c"}},
+		{name: "Equal shares", rule: RuleNameEqualShares, selected: []string{"a"}},
+		{name: "Registered", rule: "test-first", selected: []string{"a"}},
+		{name: "Unknown", rule: "unknown", err: UnknownRule{"unknown"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pb := mustOpen(t, strings.Replace(data, "rule;greedy", "rule;"+tt.rule, 1))
+			if got := pb.Rule(); got != tt.rule {
+				t.Errorf("Wrong Rule. Got %s. Expect %s.", got, tt.rule)
+			}
+
+			outcome, err := Compute(pb, nil)
+			if tt.err != nil {
+				if err != tt.err {
+					t.Errorf("Got error %v. Expect error %v.", err, tt.err)
+				}
+				return
+			}
+			mustt(t, err)
+			if !reflect.DeepEqual(outcome.Selected, tt.selected) {
+				t.Errorf("Wrong selection. Got %v. Expect %v.", outcome.Selected, tt.selected)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+type ScoringVote struct {
+	// The scored projects with the points given to them, in file order.
+	Vote []ProjectPoints
+	// The scores explicitly given to projects, indexed by project identifier.
+	// Only the first score of a project listed several times is kept.
+	Scores map[string]int
+
+	defaultScore int
+	voteBase
+}
+
+type ScoringPB struct {
+	*pbBase
+}
+
+func newScoringVote(base voteBase, defaultScore int) (ret ScoringVote) {
+	ret.voteBase = base
+	ret.defaultScore = defaultScore
+	ret.Vote = ret.mustPoints()
+	ret.Scores = make(map[string]int, len(ret.Vote))
+	for _, pp := range ret.Vote {
+		if _, dup := ret.Scores[pp.Project]; !dup {
+			ret.Scores[pp.Project] = pp.Points
+		}
+	}
+	return
+}
+
+// Score returns the score given to the project with given identifier. The
+// default score of the PB is returned for projects not mentioned in the vote.
+func (self ScoringVote) Score(project string) int {
+	if score, ok := self.Scores[project]; ok {
+		return score
+	}
+	return self.defaultScore
+}
+
+func newScoringPB(file *File) (ret ScoringPB, err error) {
+	ret = ScoringPB{}
+	if ret.pbBase, err = newPbBase(file); err != nil {
+		return
+	}
+	err = ret.checkPoints()
+	return
+}
+
+func (self ScoringPB) Vote(index int) Vote {
+	return newScoringVote(newVoteBase(self.votesSection, index), self.DefaultScore())
+}
+
+// Score returns the score given by the voter at given index to the project
+// with given identifier, applying the default score if needed.
+func (self ScoringPB) Score(voter int, project string) int {
+	return self.Vote(voter).(ScoringVote).Score(project)
+}
+
+func (self ScoringPB) MinPoints() int {
+	return self.defaultMetaInt("min_points", 0)
+}
+
+// MaxPoints returns the maximal score a vote can give to a project. There is
+// no such limit when the meta key is absent, in which case the maximal int
+// value is returned.
+func (self ScoringPB) MaxPoints() int {
+	return self.defaultMetaInt("max_points", maxInt)
+}
+
+func (self ScoringPB) DefaultScore() int {
+	return self.defaultMetaInt("default_score", 0)
+}
@@ -0,0 +1,218 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"testing"
+)
+
+type scoringVoteRepr struct {
+	id     string
+	scores map[string]int
+	// Checked only if not nil.
+	points []ProjectPoints
+}
+
+func TestScoringPB(t *testing.T) {
+	tests := []struct {
+		name         string
+		repr         []namedSection
+		minPoints    int
+		maxPoints    int
+		defaultScore int
+		votes        []scoringVoteRepr
+	}{
+		{
+			name: "full",
+			repr: []namedSection{
+				{
+					name: "META",
+					section: Section{
+						Fields: []string{"key", "value"},
+						Lines: [][]string{
+							{"num_projects", "3"},
+							{"num_votes", "2"},
+							{"budget", "1000"},
+							{"vote_type", "scoring"},
+							{"min_points", "-2"},
+							{"max_points", "2"},
+							{"default_score", "-1"},
+							{"rule", "greedy"},
+						},
+					},
+				},
+				{
+					name: "PROJECTS",
+					section: Section{
+						Fields: []string{"project_id", "cost"},
+						Lines: [][]string{
+							{"1", "999"},
+							{"2", "500"},
+							{"3", "200"},
+						},
+					},
+				},
+				{
+					name: "VOTES",
+					section: Section{
+						Fields: []string{"voter_id", "vote", "points"},
+						Lines: [][]string{
+							{"0", "1,2", "2,-2"},
+							{"1", "3", "1"},
+						},
+					},
+				},
+			},
+			minPoints:    -2,
+			maxPoints:    2,
+			defaultScore: -1,
+			votes: []scoringVoteRepr{
+				{id: "0", scores: map[string]int{"1": 2, "2": -2, "3": -1}},
+				{id: "1", scores: map[string]int{"1": -1, "2": -1, "3": 1}},
+			},
+		},
+		{
+			name: "default",
+			repr: []namedSection{
+				{
+					name: "META",
+					section: Section{
+						Fields: []string{"key", "value"},
+						Lines: [][]string{
+							{"num_projects", "2"},
+							{"num_votes", "1"},
+							{"budget", "1000"},
+							{"vote_type", "scoring"},
+							{"rule", "greedy"},
+						},
+					},
+				},
+				{
+					name: "PROJECTS",
+					section: Section{
+						Fields: []string{"project_id", "cost"},
+						Lines: [][]string{
+							{"1", "999"},
+							{"2", "500"},
+						},
+					},
+				},
+				{
+					name: "VOTES",
+					section: Section{
+						Fields: []string{"voter_id", "vote", "points"},
+						Lines: [][]string{
+							{"0", "2", "3"},
+						},
+					},
+				},
+			},
+			minPoints:    0,
+			maxPoints:    maxInt,
+			defaultScore: 0,
+			votes: []scoringVoteRepr{
+				{id: "0", scores: map[string]int{"1": 0, "2": 3}},
+			},
+		},
+		{
+			name: "duplicate",
+			repr: []namedSection{
+				{
+					name: "META",
+					section: Section{
+						Fields: []string{"key", "value"},
+						Lines: [][]string{
+							{"num_projects", "2"},
+							{"num_votes", "1"},
+							{"budget", "1000"},
+							{"vote_type", "scoring"},
+							{"rule", "greedy"},
+						},
+					},
+				},
+				{
+					name: "PROJECTS",
+					section: Section{
+						Fields: []string{"project_id", "cost"},
+						Lines: [][]string{
+							{"1", "999"},
+							{"2", "500"},
+						},
+					},
+				},
+				{
+					name: "VOTES",
+					section: Section{
+						Fields: []string{"voter_id", "vote", "points"},
+						Lines: [][]string{
+							{"0", "2,1,2", "3,1,2"},
+						},
+					},
+				},
+			},
+			minPoints:    0,
+			maxPoints:    maxInt,
+			defaultScore: 0,
+			votes: []scoringVoteRepr{
+				{
+					id:     "0",
+					scores: map[string]int{"1": 1, "2": 3},
+					points: []ProjectPoints{{"2", 3}, {"1", 1}, {"2", 2}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pb, err := newScoringPB(makeFile(tt.repr))
+			mustt(t, err)
+
+			if got := pb.MinPoints(); got != tt.minPoints {
+				t.Errorf("Wrong MinPoints. Got %d. Expect %d.", got, tt.minPoints)
+			}
+			if got := pb.MaxPoints(); got != tt.maxPoints {
+				t.Errorf("Wrong MaxPoints. Got %d. Expect %d.", got, tt.maxPoints)
+			}
+			if got := pb.DefaultScore(); got != tt.defaultScore {
+				t.Errorf("Wrong DefaultScore. Got %d. Expect %d.", got, tt.defaultScore)
+			}
+
+			for i, expect := range tt.votes {
+				vote, ok := pb.Vote(i).(ScoringVote)
+				if !ok {
+					t.Errorf("Vote %v not of type ScoringVote", pb.Vote(i))
+					continue
+				}
+				if got := vote.Id(); got != expect.id {
+					t.Errorf("Wrong Id for vote %d. Got %s. Expect %s.", i, got, expect.id)
+				}
+				got := make(map[string]int, len(expect.scores))
+				for project := range expect.scores {
+					got[project] = pb.Score(i, project)
+				}
+				if !reflect.DeepEqual(got, expect.scores) {
+					t.Errorf("Wrong scores for vote %d. Got %v. Expect %v.", i, got, expect.scores)
+				}
+				if expect.points != nil && !reflect.DeepEqual(vote.Vote, expect.points) {
+					t.Errorf("Wrong points for vote %d. Got %v. Expect %v.", i, vote.Vote, expect.points)
+				}
+			}
+		})
+	}
+}
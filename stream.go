@@ -0,0 +1,157 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"io"
+	"strings"
+)
+
+// VoteStream reads the votes of a pabulib file one at a time, without keeping
+// them in memory. The sections before VOTES are read eagerly, and sections
+// after VOTES are ignored.
+//
+// Typical use:
+//
+//	stream, err := NewVoteStream(in)
+//	if err != nil {
+//		return err
+//	}
+//	for stream.Next() {
+//		vote := stream.Vote()
+//		...
+//	}
+//	if err := stream.Err(); err != nil {
+//		return err
+//	}
+type VoteStream struct {
+	pb     PB
+	reader *sectionReader
+	// The indexes of the vote and points fields, for cumulative and scoring
+	// votes.
+	pointsIndexes []int
+
+	index int
+	vote  Vote
+	err   error
+}
+
+// NewVoteStream reads the sections of a pabulib file up to the fields of the
+// VOTES section. A MissingRequiredSection error is returned if there is no
+// VOTES section. Other errors are the same as the ones of Open, except that
+// errors in the votes are returned by Err.
+func NewVoteStream(in io.Reader) (ret *VoteStream, err error) {
+	file := &File{sections: make(map[string]*Section)}
+	scan := newLineScanner(in)
+	title := ""
+
+	var reader *sectionReader
+	for {
+		for title == "" {
+			if !scan.Scan() {
+				if err = scan.Err(); err == nil {
+					err = MissingRequiredSection{"VOTES"}
+				}
+				return
+			}
+			title = strings.TrimSpace(scan.Text())
+		}
+
+		if title == "VOTES" {
+			if reader, err = openSection(scan, title); err != nil {
+				return
+			}
+			file.sections[title] = reader.section
+			break
+		}
+		name := title
+		var section *Section
+		if section, title, err = newSection(scan, name); err != nil {
+			return
+		}
+		file.sections[name] = section
+	}
+
+	ret = &VoteStream{reader: reader, index: -1}
+	if ret.pb, err = NewPB(file); err != nil {
+		return nil, err
+	}
+	switch ret.pb.VoteType() {
+	case VoteTypeCumulative, VoteTypeScoring:
+		if ret.pointsIndexes, err = ret.pb.(basedPB).base().pointsIndexes(); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// PB returns the meta data and the projects of the file. The returned PB has
+// no vote.
+func (self *VoteStream) PB() PB {
+	return self.pb
+}
+
+// Next reads the next vote. It returns false at the end of the VOTES section,
+// or on error.
+func (self *VoteStream) Next() bool {
+	self.vote = nil
+	if self.err != nil {
+		return false
+	}
+	line, ok := self.reader.next()
+	if !ok {
+		self.err = self.reader.err
+		return false
+	}
+	self.index += 1
+
+	if self.pointsIndexes != nil {
+		if self.err = checkPointsLine(self.pointsIndexes, line, self.index); self.err != nil {
+			return false
+		}
+	}
+	section := &Section{Fields: self.reader.section.Fields, Lines: [][]string{line}, index: self.reader.section.index}
+	base := voteBase{fieldBased{section: section, line: self.index, offset: self.index}}
+	switch pb := self.pb.(type) {
+	case ApprovalPB:
+		self.vote = newApprovalVote(base)
+	case OrdinalPB:
+		self.vote = newOrdinalVote(base)
+	case CumulativePB:
+		self.vote = newCumulativeVote(base)
+	case ScoringPB:
+		self.vote = newScoringVote(base, pb.DefaultScore())
+	}
+	return true
+}
+
+// Vote returns the vote read by the last call to Next. Its concrete type is
+// the same as the votes of the corresponding PB type.
+func (self *VoteStream) Vote() Vote {
+	return self.vote
+}
+
+// Index returns the index of the vote read by the last call to Next, starting
+// at 0.
+func (self *VoteStream) Index() int {
+	return self.index
+}
+
+// Err returns the error that stopped Next, if any.
+func (self *VoteStream) Err() error {
+	return self.err
+}
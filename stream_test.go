@@ -0,0 +1,141 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVoteStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		votes   []interface{}
+		openErr error
+		err     error
+	}{
+		{
+			name: "Approval",
+			data: makeRuleData("approval", 100, []string{"a:10", "b:20"}, [][]string{{"a,b"}, {""}, {"b"}}),
+			votes: []interface{}{
+				[]string{"a", "b"},
+				[]string(nil),
+				[]string{"b"},
+			},
+		},
+		{
+			name: "Scoring",
+			data: makeRuleData("scoring", 100, []string{"a:10", "b:20"}, [][]string{{"a,b", "2,-1"}, {"b", "3"}}),
+			votes: []interface{}{
+				map[string]int{"a": 2, "b": -1},
+				map[string]int{"b": 3},
+			},
+		},
+		{
+			name: "Invalid points",
+			data: makeRuleData("cumulative", 100, []string{"a:10", "b:20"}, [][]string{{"a,b", "2,1"}, {"b", "x"}}),
+			votes: []interface{}{
+				[]ProjectPoints{{"a", 2}, {"b", 1}},
+			},
+			err: InvalidField{Section: "VOTES", Line: 1, Field: "points", Value: "x"},
+		},
+		{
+			name:  "Wrong format",
+			data:  makeRuleData("approval", 100, []string{"a:10"}, [][]string{{"a"}, {"a;a"}}),
+			votes: []interface{}{[]string{"a"}},
+			err:   WrongFormat,
+		},
+		{
+			name:    "Missing votes",
+			data:    strings.Split(makeRuleData("approval", 100, []string{"a:10"}, nil), "VOTES")[0],
+			openErr: MissingRequiredSection{"VOTES"},
+		},
+		{
+			name:    "Missing points",
+			data:    makeRuleData("cumulative", 100, []string{"a:10"}, nil),
+			openErr: MissingRequiredField{"points"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := NewVoteStream(strings.NewReader(tt.data))
+			if tt.openErr != nil {
+				if err != tt.openErr {
+					t.Errorf("Got error %v. Expect error %v.", err, tt.openErr)
+				}
+				return
+			}
+			mustt(t, err)
+			if got := stream.PB().Budget(); got != 100 {
+				t.Errorf("Wrong Budget. Got %d. Expect 100.", got)
+			}
+			if got := stream.PB().CountVotes(); got != 0 {
+				t.Errorf("Wrong NumVotes. Got %d. Expect 0.", got)
+			}
+
+			var votes []interface{}
+			for stream.Next() {
+				var got interface{}
+				switch vote := stream.Vote().(type) {
+				case ApprovalVote:
+					got = vote.Vote
+				case CumulativeVote:
+					got = vote.Vote
+				case ScoringVote:
+					got = vote.Scores
+				}
+				if id := stream.Vote().Id(); id != string(rune('0'+stream.Index())) {
+					t.Errorf("Wrong id %s for vote %d.", id, stream.Index())
+				}
+				votes = append(votes, got)
+			}
+			if !errors.Is(stream.Err(), tt.err) && stream.Err() != tt.err {
+				t.Errorf("Got error %v. Expect error %v.", stream.Err(), tt.err)
+			}
+			if !reflect.DeepEqual(votes, tt.votes) {
+				t.Errorf("Got votes %v. Expect %v.", votes, tt.votes)
+			}
+			if stream.Next() {
+				t.Errorf("Next returned true after the end.")
+			}
+		})
+	}
+}
+
+func TestVoteStream_ErrorLine(t *testing.T) {
+	data := strings.Replace(
+		makeRuleData("ordinal", 100, []string{"a:10", "b:20"}, [][]string{{"a,b"}, {"b,a"}, {"a"}}),
+		"rule;greedy\n", "rule;greedy\nmin_length;2\n", 1)
+	stream, err := NewVoteStream(strings.NewReader(data))
+	mustt(t, err)
+	pb := stream.PB().(OrdinalPB)
+	for stream.Next() {
+		_, err := pb.Scores(stream.Vote().(OrdinalVote))
+		if stream.Index() < 2 {
+			mustt(t, err)
+			continue
+		}
+		expect := InvalidField{Section: "VOTES", Line: 2, Field: "vote", Value: "a"}
+		if err != expect {
+			t.Errorf("Got error %v. Expect %v.", err, expect)
+		}
+	}
+	mustt(t, stream.Err())
+}
@@ -0,0 +1,353 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"math"
+	"sort"
+)
+
+// ThieleWeight gives the utility a voter gets from the k'th selected project
+// they approve, with k starting at 1.
+type ThieleWeight func(k int) float64
+
+// HarmonicWeight defines Proportional Approval Voting.
+func HarmonicWeight(k int) float64 {
+	return 1 / float64(k)
+}
+
+// CCWeight defines the Chamberlin-Courant rule.
+func CCWeight(k int) float64 {
+	if k == 1 {
+		return 1
+	}
+	return 0
+}
+
+// ConstantWeight defines the utilitarian approval rule.
+func ConstantWeight(k int) float64 {
+	return 1
+}
+
+// TruncatedWeight returns a weight function giving 1 to the first t selected
+// projects, and 0 to the others.
+func TruncatedWeight(t int) ThieleWeight {
+	return func(k int) float64 {
+		if k <= t {
+			return 1
+		}
+		return 0
+	}
+}
+
+type ThieleOptions struct {
+	// The weight function. Nil means HarmonicWeight.
+	Weight ThieleWeight
+	// How ties are broken. Nil means file order for SequentialThiele, and any
+	// optimal set for ExactThiele.
+	Ties TieBreaker
+}
+
+type ThieleOutcome struct {
+	Outcome
+
+	// The Thiele score of the selected projects.
+	Score float64
+}
+
+// SequentialThiele computes the outcome of the sequential Thiele rule on
+// approval votes. At each step, among the projects fitting in the remaining
+// budget, the one maximizing the increase of the Thiele score per unit of cost
+// is selected. The computation stops when no project increases the score.
+// Ties are broken by the tie breaker of the options. The score of each step of
+// the trace is the increase per unit of cost.
+func SequentialThiele(pb PB, opts ThieleOptions) (ret ThieleOutcome, err error) {
+	state, err := newThieleState(pb, opts)
+	if err != nil {
+		return
+	}
+
+	var (
+		selected []int
+		trace    []Step
+	)
+	remaining := pb.Budget()
+	available := make([]bool, len(state.costs))
+	gains := make([]float64, len(state.costs))
+	for i := range available {
+		available[i] = true
+	}
+
+	for {
+		var (
+			tied      []int
+			bestRatio float64
+		)
+		for project, ok := range available {
+			if !ok || state.costs[project] > remaining {
+				continue
+			}
+			gain := state.gain(project)
+			if gain <= 0 || sameWelfare(gain, 0) {
+				continue
+			}
+			gains[project] = gain
+			ratio := state.ratio(gain, project)
+			switch {
+			case len(tied) == 0 || (ratio > bestRatio && !sameWelfare(ratio, bestRatio)):
+				tied, bestRatio = append(tied[:0], project), ratio
+			case ratio == bestRatio || sameWelfare(ratio, bestRatio):
+				tied = append(tied, project)
+			}
+		}
+		if len(tied) == 0 {
+			break
+		}
+		best := breakTie(pb, opts.Ties, tied)
+		bestGain := gains[best]
+
+		available[best] = false
+		state.add(best)
+		remaining -= state.costs[best]
+		selected = append(selected, best)
+		ret.Score += bestGain
+		trace = append(trace, Step{
+			Project:   state.ids[best],
+			Score:     bestRatio,
+			Cost:      state.costs[best],
+			Selected:  true,
+			Remaining: remaining,
+		})
+	}
+
+	ret.Outcome = newOutcome(pb, selected)
+	ret.Trace = trace
+	return
+}
+
+// ExactThiele computes a set of projects maximizing the Thiele score on
+// approval votes under the budget constraint, by branch and bound. The weight
+// function must be non-increasing. The running time is exponential in the
+// number of projects in the worst case. With a tie breaker, the projects are
+// chosen one at a time by the tie breaker, among those belonging to an optimal
+// set containing the already chosen ones, which requires one search per chosen
+// and candidate project. Selected projects are listed in file order.
+func ExactThiele(pb PB, opts ThieleOptions) (ret ThieleOutcome, err error) {
+	state, err := newThieleState(pb, opts)
+	if err != nil {
+		return
+	}
+
+	order := make([]int, 0, len(state.costs))
+	budget := pb.Budget()
+	for project, cost := range state.costs {
+		if cost <= budget && len(state.supporters[project]) > 0 {
+			order = append(order, project)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return state.ratio(state.gain(order[i]), order[i]) > state.ratio(state.gain(order[j]), order[j])
+	})
+
+	search := thieleSearch{state: state, order: order, bestScore: -1}
+	search.search(0, budget, 0)
+	selected := search.best
+	ret.Score = search.bestScore
+	if opts.Ties != nil {
+		selected = state.breakTies(pb, opts.Ties, order, budget, ret.Score)
+	}
+
+	sort.Ints(selected)
+	ret.Outcome = newOutcome(pb, selected)
+	return
+}
+
+type thieleState struct {
+	weight     ThieleWeight
+	ids        []string
+	costs      []int
+	supporters [][]int
+	// The number of selected projects approved by each voter.
+	counts []int
+}
+
+func newThieleState(pb PB, opts ThieleOptions) (ret *thieleState, err error) {
+	if pb.VoteType() != VoteTypeApproval {
+		return nil, UnsupportedVoteType
+	}
+	ballots, err := profile(pb)
+	if err != nil {
+		return
+	}
+
+	ret = &thieleState{
+		weight:     opts.Weight,
+		ids:        projectIds(pb),
+		costs:      projectCosts(pb),
+		supporters: make([][]int, pb.CountProjects()),
+		counts:     make([]int, len(ballots)),
+	}
+	if ret.weight == nil {
+		ret.weight = HarmonicWeight
+	}
+	for voter, b := range ballots {
+		for _, project := range b.projects {
+			ret.supporters[project] = append(ret.supporters[project], voter)
+		}
+	}
+	return
+}
+
+// gain returns the increase of the score if the project were added.
+func (self *thieleState) gain(project int) (ret float64) {
+	for _, voter := range self.supporters[project] {
+		ret += self.weight(self.counts[voter] + 1)
+	}
+	return
+}
+
+// ratio returns the gain per unit of cost. Free projects have infinite ratio
+// as soon as their gain is positive.
+func (self *thieleState) ratio(gain float64, project int) float64 {
+	if cost := self.costs[project]; cost > 0 {
+		return gain / float64(cost)
+	}
+	if gain > 0 {
+		return math.MaxFloat64
+	}
+	return 0
+}
+
+func (self *thieleState) add(project int) {
+	for _, voter := range self.supporters[project] {
+		self.counts[voter] += 1
+	}
+}
+
+func (self *thieleState) remove(project int) {
+	for _, voter := range self.supporters[project] {
+		self.counts[voter] -= 1
+	}
+}
+
+// breakTies builds an optimal set by adding one project at a time, chosen by
+// the tie breaker among the projects increasing the score and belonging to an
+// optimal set containing the projects already added.
+func (self *thieleState) breakTies(pb PB, ties TieBreaker, order []int, budget int, optimum float64) (
+	selected []int,
+) {
+	undecided := append([]int(nil), order...)
+	score := 0.
+	for {
+		var tied []int
+		for i, project := range undecided {
+			cost, gain := self.costs[project], self.gain(project)
+			if cost > budget || gain <= 0 || sameWelfare(gain, 0) {
+				continue
+			}
+			others := make([]int, 0, len(undecided)-1)
+			others = append(append(others, undecided[:i]...), undecided[i+1:]...)
+			self.add(project)
+			search := thieleSearch{state: self, order: others, bestScore: -1}
+			search.search(0, budget-cost, score+gain)
+			self.remove(project)
+			if sameWelfare(search.bestScore, optimum) {
+				tied = append(tied, project)
+			}
+		}
+		if len(tied) == 0 {
+			return
+		}
+
+		sort.Ints(tied)
+		chosen := breakTie(pb, ties, tied)
+		score += self.gain(chosen)
+		self.add(chosen)
+		budget -= self.costs[chosen]
+		selected = append(selected, chosen)
+		for i, project := range undecided {
+			if project == chosen {
+				undecided = append(undecided[:i], undecided[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+type thieleSearch struct {
+	state     *thieleState
+	order     []int
+	current   []int
+	best      []int
+	bestScore float64
+}
+
+func (self *thieleSearch) search(pos, remaining int, score float64) {
+	if pos == len(self.order) {
+		if score > self.bestScore && !sameWelfare(score, self.bestScore) {
+			self.bestScore = score
+			self.best = append(self.best[:0], self.current...)
+		}
+		return
+	}
+	if bound := self.bound(pos, remaining, score); bound < self.bestScore || sameWelfare(bound, self.bestScore) {
+		return
+	}
+
+	project := self.order[pos]
+	if cost := self.state.costs[project]; cost <= remaining {
+		gain := self.state.gain(project)
+		self.state.add(project)
+		self.current = append(self.current, project)
+		self.search(pos+1, remaining-cost, score+gain)
+		self.current = self.current[:len(self.current)-1]
+		self.state.remove(project)
+	}
+	self.search(pos+1, remaining, score)
+}
+
+// bound returns an upper bound of the score reachable by adding projects from
+// the given position. Since the weights are non-increasing, the current gain
+// of each project bounds its future gain, and the fractional knapsack over
+// these gains bounds the total gain.
+func (self *thieleSearch) bound(pos, remaining int, score float64) float64 {
+	type item struct {
+		gain  float64
+		cost  int
+		ratio float64
+	}
+	items := make([]item, 0, len(self.order)-pos)
+	for _, project := range self.order[pos:] {
+		gain := self.state.gain(project)
+		if gain > 0 {
+			items = append(items, item{gain, self.state.costs[project], self.state.ratio(gain, project)})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ratio > items[j].ratio
+	})
+
+	for _, it := range items {
+		if it.cost <= remaining {
+			remaining -= it.cost
+			score += it.gain
+		} else {
+			return score + it.gain*float64(remaining)/float64(it.cost)
+		}
+	}
+	return score
+}
@@ -0,0 +1,100 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestThiele(t *testing.T) {
+	overlapping := makeRuleData("approval", 2, []string{"a:1", "b:1", "c:1"},
+		[][]string{{"a,b"}, {"a,b"}, {"a,b"}, {"c"}})
+	costly := makeRuleData("approval", 10, []string{"a:7", "b:5", "c:5"},
+		[][]string{{"a,b"}, {"a,b"}, {"a,c"}, {"a,c"}, {"a"}, {"b"}, {"c"}})
+
+	tests := []struct {
+		name       string
+		data       string
+		opts       ThieleOptions
+		sequential []string
+		exact      []string
+		score      float64
+	}{
+		{
+			name:       "PAV",
+			data:       overlapping,
+			sequential: []string{"a", "b"},
+			exact:      []string{"a", "b"},
+			score:      4.5,
+		},
+		{
+			name:       "CC",
+			data:       overlapping,
+			opts:       ThieleOptions{Weight: CCWeight},
+			sequential: []string{"a", "c"},
+			exact:      []string{"a", "c"},
+			score:      4,
+		},
+		{
+			name:       "Truncated",
+			data:       overlapping,
+			opts:       ThieleOptions{Weight: TruncatedWeight(2)},
+			sequential: []string{"a", "b"},
+			exact:      []string{"a", "b"},
+			score:      6,
+		},
+		{
+			name:       "Costly",
+			data:       costly,
+			sequential: []string{"a"},
+			exact:      []string{"b", "c"},
+			score:      6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pb := mustOpen(t, tt.data)
+
+			outcome, err := SequentialThiele(pb, tt.opts)
+			mustt(t, err)
+			if !reflect.DeepEqual(outcome.Selected, tt.sequential) {
+				t.Errorf("Wrong sequential selection. Got %v. Expect %v.", outcome.Selected, tt.sequential)
+			}
+
+			outcome, err = ExactThiele(pb, tt.opts)
+			mustt(t, err)
+			if !reflect.DeepEqual(outcome.Selected, tt.exact) {
+				t.Errorf("Wrong exact selection. Got %v. Expect %v.", outcome.Selected, tt.exact)
+			}
+			if !sameWelfare(outcome.Score, tt.score) {
+				t.Errorf("Wrong exact score. Got %f. Expect %f.", outcome.Score, tt.score)
+			}
+		})
+	}
+}
+
+func TestThiele_VoteType(t *testing.T) {
+	pb := mustOpen(t, makeRuleData("cumulative", 20, []string{"a:10", "b:10"},
+		[][]string{{"a,b", "1,9"}}))
+	if _, err := SequentialThiele(pb, ThieleOptions{}); err != UnsupportedVoteType {
+		t.Errorf("Got error %v. Expect error %v.", err, UnsupportedVoteType)
+	}
+	if _, err := ExactThiele(pb, ThieleOptions{}); err != UnsupportedVoteType {
+		t.Errorf("Got error %v. Expect error %v.", err, UnsupportedVoteType)
+	}
+}
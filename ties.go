@@ -0,0 +1,179 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// TieBreaker chooses among projects a rule cannot distinguish.
+type TieBreaker interface {
+	// Choose returns the position in tied of the chosen project. Projects are
+	// given by their index in the PB, in file order. There are always at least
+	// two tied projects.
+	Choose(pb PB, tied []int) int
+}
+
+// Predefined tie breakers.
+var (
+	// TieFileOrder chooses the project appearing first in the file. This is
+	// the behaviour of all rules when no tie breaker is given.
+	TieFileOrder TieBreaker = orderTies(func(pb PB, a, b int) bool {
+		return a < b
+	})
+	// TieLexicographic chooses the project with the smallest identifier.
+	TieLexicographic TieBreaker = orderTies(func(pb PB, a, b int) bool {
+		return pb.ProjectByIndex(a).Id() < pb.ProjectByIndex(b).Id()
+	})
+	// TieLowerCost chooses the cheapest project.
+	TieLowerCost TieBreaker = orderTies(func(pb PB, a, b int) bool {
+		return pb.ProjectByIndex(a).Cost() < pb.ProjectByIndex(b).Cost()
+	})
+	// TieHigherCost chooses the most expensive project.
+	TieHigherCost TieBreaker = orderTies(func(pb PB, a, b int) bool {
+		return pb.ProjectByIndex(a).Cost() > pb.ProjectByIndex(b).Cost()
+	})
+)
+
+// TieRandom returns a tie breaker following a pseudo-random order of the
+// projects. The order depends only on the seed and on the identifiers of the
+// projects, hence it is the same for all the ties of a computation.
+func TieRandom(seed int64) TieBreaker {
+	var prefix [8]byte
+	binary.LittleEndian.PutUint64(prefix[:], uint64(seed))
+	rank := func(id string) uint64 {
+		hash := fnv.New64a()
+		hash.Write(prefix[:])
+		hash.Write([]byte(id))
+		return hash.Sum64()
+	}
+	return orderTies(func(pb PB, a, b int) bool {
+		return rank(pb.ProjectByIndex(a).Id()) < rank(pb.ProjectByIndex(b).Id())
+	})
+}
+
+// TieExplicit returns a tie breaker choosing the project appearing first in
+// the given list of identifiers. Projects not in the list come after all the
+// listed ones.
+func TieExplicit(order []string) TieBreaker {
+	rank := make(map[string]int, len(order))
+	for i, id := range order {
+		if _, dup := rank[id]; !dup {
+			rank[id] = i
+		}
+	}
+	get := func(pb PB, project int) int {
+		if ret, ok := rank[pb.ProjectByIndex(project).Id()]; ok {
+			return ret
+		}
+		return len(order)
+	}
+	return orderTies(func(pb PB, a, b int) bool {
+		return get(pb, a) < get(pb, b)
+	})
+}
+
+// orderTies chooses the least project according to a strict order. Projects
+// that are not comparable are chosen in file order.
+type orderTies func(pb PB, a, b int) bool
+
+func (self orderTies) Choose(pb PB, tied []int) int {
+	ret := 0
+	for i := 1; i < len(tied); i++ {
+		if self(pb, tied[i], tied[ret]) {
+			ret = i
+		}
+	}
+	return ret
+}
+
+// breakTie returns the chosen project among the tied ones, which must be in
+// file order. A nil tie breaker chooses the first project.
+func breakTie(pb PB, ties TieBreaker, tied []int) int {
+	if ties == nil || len(tied) == 1 {
+		return tied[0]
+	}
+	return tied[ties.Choose(pb, tied)]
+}
+
+// EnumerateTies computes the outcomes of a rule for all the ways to break its
+// ties. The rule is run once per sequence of choices, with a tie breaker
+// following that sequence, hence it must not depend on anything else than
+// that tie breaker. Outcomes selecting the same set of projects are reported
+// only once, in the order in which they have been found. The number of runs
+// is exponential in the number of ties in the worst case.
+func EnumerateTies(rule func(ties TieBreaker) (Outcome, error)) (ret []Outcome, err error) {
+	script := &scriptedTies{}
+	found := make(map[string]bool)
+	for {
+		var outcome Outcome
+		outcome, err = rule(script)
+		if err != nil {
+			return nil, err
+		}
+
+		key := append([]string(nil), outcome.Selected...)
+		sort.Strings(key)
+		if joined := strings.Join(key, "\x00"); !found[joined] {
+			found[joined] = true
+			ret = append(ret, outcome)
+		}
+
+		if !script.next() {
+			return
+		}
+	}
+}
+
+// scriptedTies replays a sequence of choices, choosing the first project at
+// each tie not yet in the sequence.
+type scriptedTies struct {
+	choices []int
+	// The number of tied projects at each choice.
+	sizes []int
+	pos   int
+}
+
+func (self *scriptedTies) Choose(pb PB, tied []int) int {
+	if self.pos == len(self.choices) {
+		self.choices = append(self.choices, 0)
+		self.sizes = append(self.sizes, len(tied))
+	}
+	ret := self.choices[self.pos]
+	self.pos += 1
+	if ret >= len(tied) {
+		ret = len(tied) - 1
+	}
+	return ret
+}
+
+// next prepares the sequence of choices following the last one replayed, in
+// depth-first order. It returns false when all sequences have been explored.
+func (self *scriptedTies) next() bool {
+	for i := self.pos - 1; i >= 0; i-- {
+		if self.choices[i]+1 < self.sizes[i] {
+			self.choices[i] += 1
+			self.choices, self.sizes = self.choices[:i+1], self.sizes[:i+1]
+			self.pos = 0
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTieBreaker_Choose(t *testing.T) {
+	pb := mustOpen(t, makeRuleData("approval", 100, []string{"c:30", "a:50", "b:40", "d:30"},
+		[][]string{{"a"}}))
+	tied := []int{0, 1, 2, 3}
+
+	tests := []struct {
+		name   string
+		ties   TieBreaker
+		expect int
+	}{
+		{name: "File order", ties: TieFileOrder, expect: 0},
+		{name: "Lexicographic", ties: TieLexicographic, expect: 1},
+		{name: "Lower cost", ties: TieLowerCost, expect: 0},
+		{name: "Higher cost", ties: TieHigherCost, expect: 1},
+		{name: "Explicit", ties: TieExplicit([]string{"e", "d", "b"}), expect: 3},
+		{name: "Explicit empty", ties: TieExplicit(nil), expect: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ties.Choose(pb, tied); got != tt.expect {
+				t.Errorf("Got %d. Expect %d.", got, tt.expect)
+			}
+		})
+	}
+
+	t.Run("Random", func(t *testing.T) {
+		first := TieRandom(42).Choose(pb, tied)
+		if first < 0 || first >= len(tied) {
+			t.Fatalf("Got %d. Expect a position in tied.", first)
+		}
+		if again := TieRandom(42).Choose(pb, tied); again != first {
+			t.Errorf("Same seed, different choices: %d and %d.", first, again)
+		}
+		chosen := tied[first]
+		for _, project := range tied {
+			if project == chosen {
+				continue
+			}
+			pair := []int{project, chosen}
+			if got := TieRandom(42).Choose(pb, pair); got != 1 {
+				t.Errorf("Inconsistent order between %d and %d.", project, chosen)
+			}
+		}
+	})
+}
+
+func TestRules_Ties(t *testing.T) {
+	// All projects have the same support.
+	data := makeRuleData("approval", 60, []string{"a:30", "b:30", "c:20"},
+		[][]string{{"a"}, {"b"}, {"c"}})
+	greedy := func(ties TieBreaker) RuleFunc {
+		return func(pb PB, _ TieBreaker) (Outcome, error) {
+			return Greedy(pb, GreedyOptions{Ties: ties})
+		}
+	}
+	phragmen := func(ties TieBreaker) RuleFunc {
+		return func(pb PB, _ TieBreaker) (Outcome, error) {
+			outcome, err := Phragmen(pb, PhragmenOptions{Ties: ties})
+			return outcome.Outcome, err
+		}
+	}
+	utilitarian := func(ties TieBreaker) RuleFunc {
+		return func(pb PB, _ TieBreaker) (Outcome, error) {
+			outcome, err := Utilitarian(pb, UtilitarianOptions{Ties: ties})
+			return outcome.Outcome, err
+		}
+	}
+	exactThiele := func(ties TieBreaker) RuleFunc {
+		return func(pb PB, _ TieBreaker) (Outcome, error) {
+			outcome, err := ExactThiele(pb, ThieleOptions{Ties: ties})
+			return outcome.Outcome, err
+		}
+	}
+
+	tests := []struct {
+		name     string
+		data     string
+		rule     RuleFunc
+		selected []string
+	}{
+		{name: "Greedy default", rule: greedy(nil), selected: []string{"a", "b"}},
+		{name: "Greedy lower cost", rule: greedy(TieLowerCost), selected: []string{"c", "a"}},
+		{name: "Greedy explicit", rule: greedy(TieExplicit([]string{"b", "c"})), selected: []string{"b", "c"}},
+		{name: "Phragmen default", rule: phragmen(nil), selected: []string{"c", "a"}},
+		{name: "Phragmen explicit", rule: phragmen(TieExplicit([]string{"b"})), selected: []string{"c", "b"}},
+		{name: "Utilitarian lexicographic", rule: utilitarian(TieLexicographic), selected: []string{"a", "b"}},
+		{name: "Utilitarian explicit", rule: utilitarian(TieExplicit([]string{"c", "b"})), selected: []string{"b", "c"}},
+		{name: "Exact Thiele explicit", rule: exactThiele(TieExplicit([]string{"c", "b"})), selected: []string{"b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, err := tt.rule(mustOpen(t, data), nil)
+			mustt(t, err)
+			if !reflect.DeepEqual(outcome.Selected, tt.selected) {
+				t.Errorf("Wrong selection. Got %v. Expect %v.", outcome.Selected, tt.selected)
+			}
+		})
+	}
+}
+
+func TestComputeAll(t *testing.T) {
+	data := makeRuleData("approval", 60, []string{"a:30", "b:30", "c:30"},
+		[][]string{{"a"}, {"b"}, {"c"}})
+	tests := []struct {
+		rule     string
+		selected [][]string
+	}{
+		{rule: RuleNameGreedy, selected: [][]string{{"a", "b"}, {"a", "c"}, {"b", "c"}}},
+		{rule: RuleNameUnitCost, selected: [][]string{{"a", "b"}, {"a", "c"}, {"b", "c"}}},
+		{rule: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			pb := mustOpen(t, strings.Replace(data, "rule;greedy", "rule;"+tt.rule, 1))
+			outcomes, err := ComputeAll(pb)
+			if tt.selected == nil {
+				if err != (UnknownRule{tt.rule}) {
+					t.Errorf("Got error %v. Expect UnknownRule.", err)
+				}
+				return
+			}
+			mustt(t, err)
+
+			got := make([][]string, len(outcomes))
+			for i, outcome := range outcomes {
+				got[i] = outcome.Selected
+			}
+			if !reflect.DeepEqual(got, tt.selected) {
+				t.Errorf("Wrong outcomes. Got %v. Expect %v.", got, tt.selected)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultMaxTableSize is the default value of UtilitarianOptions.MaxTableSize.
+const DefaultMaxTableSize = 1 << 24
+
+type UtilitarianOptions struct {
+	// The maximal number of cells of the dynamic programming table, which is
+	// the number of projects times the budget divided by the greatest common
+	// divisor of the costs. Larger instances are solved by branch and bound.
+	// Zero means DefaultMaxTableSize.
+	MaxTableSize int
+	// How ties are broken. Nil means the cheapest optimal set.
+	Ties TieBreaker
+}
+
+type UtilitarianOutcome struct {
+	Outcome
+
+	// The total utility of the selected projects.
+	Welfare float64
+	// Whether no other set of projects reaches the same welfare.
+	Unique bool
+}
+
+// Utilitarian computes a set of projects maximizing the total utility of the
+// voters (number of approvals, sum of points or sum of ordinal scores) under the
+// budget constraint. Projects with a non-positive total utility are never
+// selected and are not considered when checking uniqueness. Without tie
+// breaker, the cheapest optimal set is returned. Otherwise, the projects are
+// chosen one at a time by the tie breaker, among those belonging to an optimal
+// set containing the already chosen ones. This requires solving the problem
+// once per chosen and candidate project. Selected projects are listed in file
+// order.
+func Utilitarian(pb PB, opts UtilitarianOptions) (ret UtilitarianOutcome, err error) {
+	ballots, err := profile(pb)
+	if err != nil {
+		return
+	}
+	budget := pb.Budget()
+	values := totalUtilities(pb.CountProjects(), ballots)
+	costs := projectCosts(pb)
+
+	var items []int
+	for project, value := range values {
+		if value > 0 && costs[project] <= budget {
+			items = append(items, project)
+		}
+	}
+
+	solver := knapsackSolver{values: values, costs: costs, maxSize: opts.MaxTableSize}
+	if solver.maxSize <= 0 {
+		solver.maxSize = DefaultMaxTableSize
+	}
+	var selected []int
+	selected, ret.Welfare, ret.Unique = solver.solve(items, budget)
+	if opts.Ties != nil && !ret.Unique {
+		selected = solver.breakTies(pb, opts.Ties, items, budget, ret.Welfare)
+	}
+
+	sort.Ints(selected)
+	ret.Outcome = newOutcome(pb, selected)
+	return
+}
+
+type knapsackSolver struct {
+	values  []float64
+	costs   []int
+	maxSize int
+}
+
+// solve chooses the algorithm depending on the size of the dynamic programming
+// table.
+func (self knapsackSolver) solve(items []int, budget int) (selected []int, welfare float64, unique bool) {
+	divisor := budget
+	for _, project := range items {
+		divisor = gcd(divisor, self.costs[project])
+	}
+	if divisor == 0 || (len(items)+1)*(budget/divisor+1) <= self.maxSize {
+		return knapsackDP(items, self.values, self.costs, budget, divisor)
+	}
+	return knapsackBB(items, self.values, self.costs, budget)
+}
+
+// breakTies builds an optimal set by adding one project at a time, chosen by
+// the tie breaker among the projects belonging to an optimal set containing
+// the projects already added.
+func (self knapsackSolver) breakTies(pb PB, ties TieBreaker, items []int, budget int, welfare float64) (
+	selected []int,
+) {
+	undecided := append([]int(nil), items...)
+	value := 0.
+	for {
+		var tied []int
+		for i, project := range undecided {
+			cost := self.costs[project]
+			if cost > budget {
+				continue
+			}
+			others := make([]int, 0, len(undecided)-1)
+			others = append(append(others, undecided[:i]...), undecided[i+1:]...)
+			_, rest, _ := self.solve(others, budget-cost)
+			if sameWelfare(value+self.values[project]+rest, welfare) {
+				tied = append(tied, project)
+			}
+		}
+		if len(tied) == 0 {
+			return
+		}
+
+		chosen := breakTie(pb, ties, tied)
+		selected = append(selected, chosen)
+		value += self.values[chosen]
+		budget -= self.costs[chosen]
+		for i, project := range undecided {
+			if project == chosen {
+				undecided = append(undecided[:i], undecided[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func sameWelfare(a, b float64) bool {
+	return floatNumber(a).Cmp(floatNumber(b)) == 0
+}
+
+// knapsackDP solves the problem by dynamic programming over the total cost of
+// the selected items, divided by the given divisor.
+func knapsackDP(items []int, values []float64, costs []int, budget, divisor int) (
+	selected []int, welfare float64, unique bool,
+) {
+	capacity := 0
+	if divisor > 0 {
+		capacity = budget / divisor
+	}
+
+	// best[c] is the maximal welfare of sets of items of total cost exactly c,
+	// and count[c] the number of such sets, up to 2.
+	best := make([]float64, capacity+1)
+	count := make([]int, capacity+1)
+	for c := range best {
+		best[c] = math.Inf(-1)
+	}
+	best[0], count[0] = 0, 1
+	take := make([][]bool, len(items))
+
+	for i, project := range items {
+		weight, value := 0, values[project]
+		if divisor > 0 {
+			weight = costs[project] / divisor
+		}
+		take[i] = make([]bool, capacity+1)
+		for c := capacity; c >= weight; c-- {
+			if count[c-weight] == 0 {
+				continue
+			}
+			candidate := best[c-weight] + value
+			if count[c] > 0 && sameWelfare(candidate, best[c]) {
+				count[c] = minInt(2, count[c]+count[c-weight])
+			} else if count[c] == 0 || candidate > best[c] {
+				best[c], count[c] = candidate, count[c-weight]
+				take[i][c] = true
+			}
+		}
+	}
+
+	target, total := -1, 0
+	for c := range best {
+		if count[c] == 0 {
+			continue
+		}
+		if target < 0 || (best[c] > best[target] && !sameWelfare(best[c], best[target])) {
+			target, total = c, count[c]
+		} else if sameWelfare(best[c], best[target]) {
+			total += count[c]
+		}
+	}
+	welfare = best[target]
+	unique = total == 1
+
+	for i, c := len(items)-1, target; i >= 0; i-- {
+		if take[i][c] {
+			selected = append(selected, items[i])
+			if divisor > 0 {
+				c -= costs[items[i]] / divisor
+			}
+		}
+	}
+	return
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// knapsackBB solves the problem by branch and bound, using the fractional
+// relaxation as bound.
+func knapsackBB(items []int, values []float64, costs []int, budget int) (
+	selected []int, welfare float64, unique bool,
+) {
+	sorted := append([]int(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		return values[a]*float64(costs[b]) > values[b]*float64(costs[a])
+	})
+
+	bb := knapsackSearch{
+		budget:  budget,
+		items:   sorted,
+		values:  values,
+		costs:   costs,
+		welfare: math.Inf(-1),
+		current: make([]int, 0, len(sorted)),
+	}
+	bb.search(0, budget, 0)
+	return bb.best, bb.welfare, bb.count == 1
+}
+
+type knapsackSearch struct {
+	budget   int
+	items    []int
+	values   []float64
+	costs    []int
+	current  []int
+	best     []int
+	bestCost int
+	welfare  float64
+	count    int
+}
+
+func (self *knapsackSearch) search(pos, remaining int, value float64) {
+	if pos == len(self.items) {
+		cost := self.budget - remaining
+		if self.count > 0 && sameWelfare(value, self.welfare) {
+			self.count += 1
+			if cost < self.bestCost {
+				self.keep(cost)
+			}
+		} else if value > self.welfare {
+			self.welfare, self.count = value, 1
+			self.keep(cost)
+		}
+		return
+	}
+
+	if self.count > 0 {
+		bound := self.bound(pos, remaining, value)
+		if bound < self.welfare && !sameWelfare(bound, self.welfare) {
+			return
+		}
+	}
+
+	project := self.items[pos]
+	if cost := self.costs[project]; cost <= remaining {
+		self.current = append(self.current, project)
+		self.search(pos+1, remaining-cost, value+self.values[project])
+		self.current = self.current[:len(self.current)-1]
+	}
+	self.search(pos+1, remaining, value)
+}
+
+func (self *knapsackSearch) keep(cost int) {
+	self.best = append(self.best[:0], self.current...)
+	self.bestCost = cost
+}
+
+// bound returns the optimal value of the fractional relaxation on the
+// remaining items.
+func (self *knapsackSearch) bound(pos, remaining int, value float64) float64 {
+	for _, project := range self.items[pos:] {
+		cost := self.costs[project]
+		if cost <= remaining {
+			remaining -= cost
+			value += self.values[project]
+		} else {
+			return value + self.values[project]*float64(remaining)/float64(cost)
+		}
+	}
+	return value
+}
@@ -0,0 +1,95 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUtilitarian(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		selected []string
+		welfare  float64
+		unique   bool
+	}{
+		{
+			name: "Tie",
+			data: makeRuleData("approval", 100, []string{"a:60", "b:40", "c:30", "d:50"},
+				[][]string{{"a,d"}, {"a,d"}, {"a"}, {"b"}, {"c"}}),
+			selected: []string{"a", "c"},
+			welfare:  4,
+			unique:   false,
+		},
+		{
+			name: "Unique",
+			data: makeRuleData("approval", 100, []string{"a:60", "b:40", "c:30"},
+				[][]string{{"a,b"}, {"a,b"}, {"a"}, {"c"}}),
+			selected: []string{"a", "b"},
+			welfare:  5,
+			unique:   true,
+		},
+		{
+			name: "Not greedy",
+			data: makeRuleData("approval", 100, []string{"a:70", "b:50", "c:50"},
+				[][]string{{"a,b"}, {"a,c"}, {"a"}, {"b"}, {"c"}}),
+			selected: []string{"b", "c"},
+			welfare:  4,
+			unique:   true,
+		},
+		{
+			name: "Free project",
+			data: makeRuleData("approval", 100, []string{"a:60", "b:0", "c:30", "d:200"},
+				[][]string{{"a,b,d"}, {"a,d"}, {"c"}}),
+			selected: []string{"a", "b", "c"},
+			welfare:  4,
+			unique:   true,
+		},
+		{
+			name: "Cumulative",
+			data: makeRuleData("cumulative", 10, []string{"a:5", "b:5", "c:5"},
+				[][]string{{"a,b", "1,4"}, {"c,a", "2,3"}}),
+			selected: []string{"a", "b"},
+			welfare:  8,
+			unique:   true,
+		},
+	}
+	for _, tt := range tests {
+		for _, opts := range []UtilitarianOptions{{}, {MaxTableSize: 1}} {
+			name := tt.name + " DP"
+			if opts.MaxTableSize == 1 {
+				name = tt.name + " BB"
+			}
+			t.Run(name, func(t *testing.T) {
+				outcome, err := Utilitarian(mustOpen(t, tt.data), opts)
+				mustt(t, err)
+
+				if !reflect.DeepEqual(outcome.Selected, tt.selected) {
+					t.Errorf("Wrong selection. Got %v. Expect %v.", outcome.Selected, tt.selected)
+				}
+				if outcome.Welfare != tt.welfare {
+					t.Errorf("Wrong welfare. Got %f. Expect %f.", outcome.Welfare, tt.welfare)
+				}
+				if outcome.Unique != tt.unique {
+					t.Errorf("Wrong uniqueness. Got %t. Expect %t.", outcome.Unique, tt.unique)
+				}
+			})
+		}
+	}
+}
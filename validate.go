@@ -0,0 +1,303 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	SeverityWarning = iota
+	SeverityError
+)
+
+// Issue is a violation of the pabulib format found by Validate.
+type Issue struct {
+	Severity int
+	// The section where the issue has been found.
+	Section string
+	// The 1-based number of the offending line in the file, or zero when the
+	// issue is not related to a particular line or the line is unknown.
+	Line    int
+	Message string
+}
+
+func (self Issue) String() string {
+	severity := "error"
+	if self.Severity == SeverityWarning {
+		severity = "warning"
+	}
+	if self.Line <= 0 {
+		return fmt.Sprintf("%s in section %s: %s", severity, self.Section, self.Message)
+	}
+	return fmt.Sprintf("%s in section %s at line %d: %s", severity, self.Section, self.Line, self.Message)
+}
+
+// Validate checks the given PB against all the rules of the pabulib format, and
+// returns all the violations found. Issues are sorted by section (META,
+// PROJECTS, VOTES) then by line.
+func Validate(pb PB) []Issue {
+	var v validator
+	if based, ok := pb.(basedPB); ok {
+		base := based.base()
+		v.sections = map[string]*Section{"PROJECTS": base.projectsSection, "VOTES": base.votesSection}
+	}
+	v.checkCounts(pb)
+	v.checkProjects(pb)
+	v.checkVotes(pb)
+	return v.issues
+}
+
+// ValidateFile is like Validate, but reports the violations making NewPB fail
+// instead of failing. Invalid costs and points are reported for every line.
+// The other violations checked by Validate are only reported when NewPB
+// succeeds.
+func ValidateFile(file *File) []Issue {
+	pb, err := NewPB(file)
+	if err == nil {
+		return Validate(pb)
+	}
+	v := validator{sections: file.sections}
+	if _, ok := err.(InvalidField); !ok {
+		v.add(SeverityError, errorSection(err), -1, "%v", err)
+		return v.issues
+	}
+
+	// Since InvalidField is returned once all the meta keys and fields have
+	// been checked, base is complete except for the costs.
+	base, _ := newPbBase(file)
+	costIndex, _ := base.projectsSection.fieldIndex("cost")
+	for i, project := range base.projectsSection.Lines {
+		if _, err := strconv.Atoi(project[costIndex]); err != nil {
+			v.add(SeverityError, "PROJECTS", i, "invalid cost %q", project[costIndex])
+		}
+	}
+	if voteType := base.VoteType(); voteType == VoteTypeCumulative || voteType == VoteTypeScoring {
+		indexes, err := base.pointsIndexes()
+		if err != nil {
+			v.add(SeverityError, "VOTES", -1, "%v", err)
+			return v.issues
+		}
+		for i, line := range base.votesSection.Lines {
+			if checkPointsLine(indexes, line, i) != nil {
+				v.add(SeverityError, "VOTES", i, "invalid points %q", line[indexes[1]])
+			}
+		}
+	}
+	return v.issues
+}
+
+// errorSection returns the section concerned by an error of NewPB, other than
+// InvalidField.
+func errorSection(err error) string {
+	switch err := err.(type) {
+	case MissingRequiredSection:
+		return err.Section
+	case MissingRequiredField:
+		if err.Field == "project_id" || err.Field == "cost" {
+			return "PROJECTS"
+		}
+		return "VOTES"
+	default:
+		return "META"
+	}
+}
+
+type validator struct {
+	issues []Issue
+	// The sections, to find the line numbers of the records. Nil for PBs not
+	// read from a file.
+	sections map[string]*Section
+}
+
+// add adds an issue about the record at given index in the section, or about
+// no particular record if the index is negative.
+func (self *validator) add(severity int, section string, record int, format string, args ...interface{}) {
+	self.issues = append(self.issues, Issue{
+		Severity: severity,
+		Section:  section,
+		Line:     self.sections[section].lineNum(record),
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// firstUse returns a message for an identifier already used by the record at
+// given index.
+func (self *validator) firstUse(kind, id, section string, record int) string {
+	if line := self.sections[section].lineNum(record); line > 0 {
+		return fmt.Sprintf("%s id %s already used at line %d", kind, id, line)
+	}
+	return fmt.Sprintf("%s id %s already used", kind, id)
+}
+
+func (self *validator) checkCounts(pb PB) {
+	counts := []struct {
+		key   string
+		value int
+		count int
+	}{
+		{"num_projects", pb.NumProjects(), pb.CountProjects()},
+		{"num_votes", pb.NumVotes(), pb.CountVotes()},
+	}
+	for _, c := range counts {
+		if c.value != c.count {
+			self.add(SeverityError, "META", -1, "%s is %d but there are %d lines", c.key, c.value, c.count)
+		}
+	}
+}
+
+func (self *validator) checkProjects(pb PB) {
+	budget := pb.Budget()
+	seen := make(map[string]int, pb.CountProjects())
+	for i := 0; i < pb.CountProjects(); i++ {
+		project := pb.ProjectByIndex(i)
+		id := project.Id()
+		if first, dup := seen[id]; dup {
+			self.add(SeverityError, "PROJECTS", i, "%s", self.firstUse("project", id, "PROJECTS", first))
+		} else {
+			seen[id] = i
+		}
+		if cost := project.Cost(); cost > budget {
+			self.add(SeverityWarning, "PROJECTS", i, "project %s costs %d, more than the budget", id, cost)
+		}
+	}
+}
+
+// Optional bounds, implemented by some PB types.
+type (
+	lengthBounded interface {
+		MinLength() int
+		MaxLength() int
+	}
+	costBounded interface {
+		MaxSumCost() int
+	}
+	pointsBounded interface {
+		MinPoints() int
+		MaxPoints() int
+	}
+	sumPointsBounded interface {
+		MinSumPoints() int
+		MaxSumPoints() int
+	}
+)
+
+func (self *validator) checkVotes(pb PB) {
+	seen := make(map[string]int, pb.CountVotes())
+	for i := 0; i < pb.CountVotes(); i++ {
+		vote := pb.Vote(i)
+		id := vote.Id()
+		if first, dup := seen[id]; dup {
+			self.add(SeverityError, "VOTES", i, "%s", self.firstUse("voter", id, "VOTES", first))
+		} else {
+			seen[id] = i
+		}
+
+		projects, points := votedProjects(vote)
+		unique := self.checkVotedProjects(pb, i, projects)
+		self.checkVoteBounds(pb, i, projects, points, unique)
+	}
+}
+
+// votedProjects returns the projects explicitly mentioned in the vote, with
+// their points when relevant.
+func votedProjects(vote Vote) (projects []string, points []int) {
+	switch v := vote.(type) {
+	case ApprovalVote:
+		projects = v.Vote
+	case OrdinalVote:
+		projects = v.Vote
+	case CumulativeVote:
+		projects, points = splitPoints(v.Vote)
+	case ScoringVote:
+		projects, points = splitPoints(v.Vote)
+	}
+	return
+}
+
+func splitPoints(vote []ProjectPoints) (projects []string, points []int) {
+	projects = make([]string, len(vote))
+	points = make([]int, len(vote))
+	for i, pp := range vote {
+		projects[i], points[i] = pp.Project, pp.Points
+	}
+	return
+}
+
+// checkVotedProjects returns the existing projects of the vote, without
+// duplicates.
+func (self *validator) checkVotedProjects(pb PB, line int, projects []string) (unique []Project) {
+	voted := make(map[string]bool, len(projects))
+	for _, id := range projects {
+		if voted[id] {
+			self.add(SeverityError, "VOTES", line, "project %s voted more than once", id)
+			continue
+		}
+		voted[id] = true
+		if project, ok := pb.Project(id); ok {
+			unique = append(unique, project)
+		} else {
+			self.add(SeverityError, "VOTES", line, "unknown project %s", id)
+		}
+	}
+	return
+}
+
+func (self *validator) checkVoteBounds(pb PB, line int, projects []string, points []int, unique []Project) {
+	if bounded, ok := pb.(lengthBounded); ok {
+		if length, min := len(projects), bounded.MinLength(); length < min {
+			self.add(SeverityError, "VOTES", line, "vote has %d projects, less than min_length %d", length, min)
+		}
+		if length, max := len(projects), bounded.MaxLength(); length > max {
+			self.add(SeverityError, "VOTES", line, "vote has %d projects, more than max_length %d", length, max)
+		}
+	}
+
+	if bounded, ok := pb.(costBounded); ok {
+		cost := 0
+		for _, project := range unique {
+			cost += project.Cost()
+		}
+		if max := bounded.MaxSumCost(); cost > max {
+			self.add(SeverityError, "VOTES", line, "vote costs %d, more than max_sum_cost %d", cost, max)
+		}
+	}
+
+	if bounded, ok := pb.(pointsBounded); ok {
+		min, max := bounded.MinPoints(), bounded.MaxPoints()
+		for i, value := range points {
+			if value < min || value > max {
+				self.add(SeverityError, "VOTES", line, "project %s gets %d points, outside [%d, %d]",
+					projects[i], value, min, max)
+			}
+		}
+	}
+
+	if bounded, ok := pb.(sumPointsBounded); ok {
+		sum := 0
+		for _, value := range points {
+			sum += value
+		}
+		if min := bounded.MinSumPoints(); sum < min {
+			self.add(SeverityError, "VOTES", line, "vote gives %d points, less than min_sum_points %d", sum, min)
+		}
+		if max := bounded.MaxSumPoints(); sum > max {
+			self.add(SeverityError, "VOTES", line, "vote gives %d points, more than max_sum_points %d", sum, max)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"strings"
+	"testing"
+)
+
+type issueRepr struct {
+	severity int
+	section  string
+	line     int
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		issues []issueRepr
+	}{
+		{
+			name:   "Valid",
+			data:   makePBData("cumulative"),
+			issues: nil,
+		},
+		{
+			name: "Approval",
+			data: "META\nkey;value\nnum_projects;5\nnum_votes;4\nbudget;1000\nvote_type;approval\n" +
+				"rule;greedy\nmax_length;2\nmax_sum_cost;1200\n" +
+				"PROJECTS\nproject_id;cost\n1;600\n2;700\n1;600\n5;2000\n" +
+				"VOTES\nvoter_id;vote\n0;1,2\n1;1,4\n0;1,1\n3;1,2,4\n",
+			issues: []issueRepr{
+				{SeverityError, "META", 0},
+				{SeverityError, "PROJECTS", 14},
+				{SeverityWarning, "PROJECTS", 15},
+				{SeverityError, "VOTES", 18},
+				{SeverityError, "VOTES", 19},
+				{SeverityError, "VOTES", 20},
+				{SeverityError, "VOTES", 20},
+				{SeverityError, "VOTES", 21},
+				{SeverityError, "VOTES", 21},
+				{SeverityError, "VOTES", 21},
+			},
+		},
+		{
+			name: "Cumulative",
+			data: "META\nkey;value\nnum_projects;2\nnum_votes;2\nbudget;1000\nvote_type;cumulative\n" +
+				"rule;greedy\nmax_points;4\nmax_sum_points;5\n" +
+				"PROJECTS\nproject_id;cost\n1;600\n2;400\n" +
+				"VOTES\nvoter_id;vote;points\n0;1,2;5,0\n1;1,2;3,3\n",
+			issues: []issueRepr{
+				{SeverityError, "VOTES", 16},
+				{SeverityError, "VOTES", 17},
+			},
+		},
+		{
+			name: "Ordinal",
+			data: "META\nkey;value\nnum_projects;2\nnum_votes;2\nbudget;1000\nvote_type;ordinal\n" +
+				"rule;greedy\nmin_length;2\n" +
+				"PROJECTS\nproject_id;cost\n1;600\n2;400\n" +
+				"VOTES\nvoter_id;vote\n0;2,1\n1;1\n",
+			issues: []issueRepr{
+				{SeverityError, "VOTES", 16},
+			},
+		},
+		{
+			name: "Multi-line record",
+			data: "META\nkey;value\nnum_projects;2\nnum_votes;2\nbudget;1000\nvote_type;ordinal\n" +
+				"rule;greedy\nmin_length;2\n" +
+				"PROJECTS\nproject_id;cost\n1;600\n2;400\n" +
+				"VOTES\nvoter_id;vote\n0;\"2,\n1\"\n1;1\n",
+			issues: []issueRepr{
+				{SeverityError, "VOTES", 17},
+			},
+		},
+		{
+			name: "Scoring",
+			data: "META\nkey;value\nnum_projects;2\nnum_votes;2\nbudget;1000\nvote_type;scoring\n" +
+				"rule;greedy\n" +
+				"PROJECTS\nproject_id;cost\n1;600\n2;400\n" +
+				"VOTES\nvoter_id;vote;points\n0;2,1;1,2\n1;1,1;1,2\n",
+			issues: []issueRepr{
+				{SeverityError, "VOTES", 15},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pb, err := Open(strings.NewReader(tt.data))
+			mustt(t, err)
+
+			issues := Validate(pb)
+			if len(issues) != len(tt.issues) {
+				t.Errorf("Wrong number of issues. Got %v. Expect %v.", issues, tt.issues)
+				return
+			}
+			for i, expect := range tt.issues {
+				got := issues[i]
+				if got.Severity != expect.severity || got.Section != expect.section || got.Line != expect.line {
+					t.Errorf("Wrong issue %d. Got %v. Expect %v.", i, got, expect)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		issues []issueRepr
+	}{
+		{
+			name:   "Valid",
+			data:   makeRuleData("approval", 10, []string{"a:1", "b:2"}, [][]string{{"a"}}),
+			issues: nil,
+		},
+		{
+			name: "Count",
+			data: strings.Replace(makeRuleData("approval", 10, []string{"a:1", "b:2"}, [][]string{{"a"}}),
+				"num_votes;1", "num_votes;2", 1),
+			issues: []issueRepr{{SeverityError, "META", 0}},
+		},
+		{
+			name: "Invalid costs",
+			data: makeRuleData("approval", 10, []string{"a:x", "b:1", "c:2.5"}, [][]string{{"a"}}),
+			issues: []issueRepr{
+				{SeverityError, "PROJECTS", 10},
+				{SeverityError, "PROJECTS", 12},
+			},
+		},
+		{
+			name: "Invalid points",
+			data: makeRuleData("cumulative", 10, []string{"a:1", "b:1"},
+				[][]string{{"a,b", "1"}, {"a", "x"}, {"b", "1"}}),
+			issues: []issueRepr{
+				{SeverityError, "VOTES", 14},
+				{SeverityError, "VOTES", 15},
+			},
+		},
+		{
+			name:   "Missing points",
+			data:   makeRuleData("cumulative", 10, []string{"a:1", "b:1"}, [][]string{{"a"}}),
+			issues: []issueRepr{{SeverityError, "VOTES", 0}},
+		},
+		{
+			name: "Missing meta",
+			data: strings.Replace(makeRuleData("approval", 10, []string{"a:x"}, [][]string{{"a"}}),
+				"rule;greedy\n", "", 1),
+			issues: []issueRepr{{SeverityError, "META", 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ReadFile(strings.NewReader(tt.data))
+			mustt(t, err)
+
+			issues := ValidateFile(file)
+			if len(issues) != len(tt.issues) {
+				t.Errorf("Wrong number of issues. Got %v. Expect %v.", issues, tt.issues)
+				return
+			}
+			for i, expect := range tt.issues {
+				got := issues[i]
+				if got.Severity != expect.severity || got.Section != expect.section || got.Line != expect.line {
+					t.Errorf("Wrong issue %d. Got %v. Expect %v.", i, got, expect)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+var (
+	UnsupportedPB = errors.New("Unsupported PB implementation")
+)
+
+// standardSections lists the sections defined by the pabulib format, in the
+// order they are written.
+var standardSections = []string{"META", "PROJECTS", "VOTES"}
+
+// WriteTo writes the file in the pabulib format. The standard sections are
+// written first, in the order META, PROJECTS, VOTES, followed by any other
+// section in lexicographic order. Fields are written in the order of
+// Section.Fields.
+func (self *File) WriteTo(out io.Writer) (n int64, err error) {
+	counter := &countingWriter{out: out}
+	buf := bufio.NewWriter(counter)
+
+	for _, name := range self.sectionNames() {
+		writeSection(buf, name, self.sections[name])
+	}
+
+	err = buf.Flush()
+	n = counter.count
+	return
+}
+
+// WritePB writes the META, PROJECTS and VOTES sections of the given PB in the
+// pabulib format. Only the PB implementations of this package are supported.
+func WritePB(out io.Writer, pb PB) error {
+	based, ok := pb.(basedPB)
+	if !ok {
+		return UnsupportedPB
+	}
+	base := based.base()
+	file := &File{sections: map[string]*Section{
+		"META":     base.metaSection,
+		"PROJECTS": base.projectsSection,
+		"VOTES":    base.votesSection,
+	}}
+	_, err := file.WriteTo(out)
+	return err
+}
+
+func (self *File) sectionNames() []string {
+	ret := make([]string, 0, len(self.sections))
+	for _, name := range standardSections {
+		if _, ok := self.sections[name]; ok {
+			ret = append(ret, name)
+		}
+	}
+	others := len(ret)
+
+	for name := range self.sections {
+		if !isStandardSection(name) {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret[others:])
+	return ret
+}
+
+func isStandardSection(name string) bool {
+	for _, std := range standardSections {
+		if name == std {
+			return true
+		}
+	}
+	return false
+}
+
+// Errors are reported by Flush.
+func writeSection(out *bufio.Writer, name string, section *Section) {
+	out.WriteString(name)
+	out.WriteByte('\n')
+	writeRecord(out, section.Fields)
+	for _, line := range section.Lines {
+		writeRecord(out, line)
+	}
+}
+
+func writeRecord(out *bufio.Writer, record []string) {
+	for i, value := range record {
+		if i > 0 {
+			out.WriteByte(';')
+		}
+		writeValue(out, value)
+	}
+	out.WriteByte('\n')
+}
+
+// writeValue writes the value, quoting it if needed. Values are quoted when
+// they contain a semicolon, a double quote or a line break, or when they start
+// or end with a space. Double quotes are escaped by doubling them.
+func writeValue(out *bufio.Writer, value string) {
+	if !needsQuotes(value) {
+		out.WriteString(value)
+		return
+	}
+	out.WriteByte('"')
+	out.WriteString(strings.ReplaceAll(value, `"`, `""`))
+	out.WriteByte('"')
+}
+
+func needsQuotes(value string) bool {
+	if value == "" {
+		return false
+	}
+	return strings.ContainsAny(value, ";\"\r\n") || value != strings.TrimSpace(value)
+}
+
+type countingWriter struct {
+	out   io.Writer
+	count int64
+}
+
+func (self *countingWriter) Write(p []byte) (n int, err error) {
+	n, err = self.out.Write(p)
+	self.count += int64(n)
+	return
+}
@@ -0,0 +1,113 @@
+// pabulib for Go
+// Copyright (C) 2021 Joseph Boudou
+//
+// This program is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU General Public License for more
+// details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <https://www.gnu.org/licenses/>.
+
+package pabulib
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFile_WriteTo(t *testing.T) {
+	tests := []struct {
+		name   string
+		repr   []namedSection
+		output string
+	}{
+		{
+			name: "Order",
+			repr: []namedSection{
+				{name: "ZZZ", section: Section{Fields: []string{"a", "b"}}},
+				{name: "VOTES", section: Section{Fields: []string{"voter_id", "vote"}, Lines: [][]string{{"0", "1,2"}}}},
+				{name: "AAA", section: Section{Fields: []string{"a", "b"}}},
+				{name: "META", section: Section{Fields: []string{"key", "value"}, Lines: [][]string{{"budget", "1"}}}},
+				{name: "PROJECTS", section: Section{Fields: []string{"project_id", "cost"}, Lines: [][]string{{"1", "2"}}}},
+			},
+			output: "META\nkey;value\nbudget;1\n" +
+				"PROJECTS\nproject_id;cost\n1;2\n" +
+				"VOTES\nvoter_id;vote\n0;1,2\n" +
+				"AAA\na;b\n" +
+				"ZZZ\na;b\n",
+		},
+		{
+			name: "Quotes",
+			repr: []namedSection{
+				{name: "PROJECTS", section: Section{
+					Fields: []string{"project_id", "name"},
+					Lines: [][]string{
+						{"1", "a;b"},
+						{"2", `say "hi"`},
+						{"3", " padded"},
+						{"4", ""},
+						{"5", "two\nlines"},
+						{"6", "x\r\ny"},
+					},
+				}},
+			},
+			output: "PROJECTS\nproject_id;name\n" +
+				"1;\"a;b\"\n" +
+				"2;\"say \"\"hi\"\"\"\n" +
+				"3;\" padded\"\n" +
+				"4;\n" +
+				"5;\"two\nlines\"\n" +
+				"6;\"x\r\ny\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := makeFile(tt.repr)
+			var out strings.Builder
+			n, err := file.WriteTo(&out)
+			mustt(t, err)
+			if got := out.String(); got != tt.output {
+				t.Errorf("Wrong output. Got %q. Expect %q.", got, tt.output)
+			}
+			if n != int64(len(tt.output)) {
+				t.Errorf("Wrong count. Got %d. Expect %d.", n, len(tt.output))
+			}
+
+			read, err := ReadFile(strings.NewReader(out.String()))
+			mustt(t, err)
+			if len(read.sections) != len(file.sections) {
+				t.Errorf("Wrong round trip. Got %v. Expect %v.", read, file)
+			}
+			for name, expect := range file.sections {
+				got, ok := read.Get(name)
+				if !ok || !reflect.DeepEqual(got.Fields, expect.Fields) || !reflect.DeepEqual(got.Lines, expect.Lines) {
+					t.Errorf("Wrong round trip of section %s. Got %v. Expect %v.", name, got, expect)
+				}
+			}
+		})
+	}
+}
+
+func TestWritePB(t *testing.T) {
+	data := makePBData("cumulative")
+	pb, err := Open(strings.NewReader(data))
+	mustt(t, err)
+
+	var out strings.Builder
+	mustt(t, WritePB(&out, pb))
+
+	orig, err := ReadFile(strings.NewReader(data))
+	mustt(t, err)
+	got, err := ReadFile(strings.NewReader(out.String()))
+	mustt(t, err)
+	if !reflect.DeepEqual(got, orig) {
+		t.Errorf("Wrong round trip. Got %v. Expect %v.", got, orig)
+	}
+}